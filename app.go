@@ -1,32 +1,54 @@
 package gowatch
 
 import (
+	"bytes"
+	"context"
+	"errors"
 	"fmt"
+	"hash/fnv"
+	"io"
 	"os"
 	"os/exec"
+	"path"
+	"path/filepath"
+	"runtime"
 	"strings"
+	"syscall"
+	"text/template"
+	"time"
+)
 
-	"github.com/sirupsen/logrus"
+const (
+	//DefaultKillTimeout time to wait for the app to exit after the
+	//stop signal was sent before killing it
+	DefaultKillTimeout = 5 * time.Second
 )
 
+//DefaultStopSignal signal sent to the app to ask it to exit
+var DefaultStopSignal os.Signal = syscall.SIGTERM
+
 //App manage go apps
 type App interface {
-	//Compile compile app
-	Compile() error
+	//Compile compile app, aborting the build if ctx is done
+	Compile(ctx context.Context) error
 
-	//Start start app and return cmd executable
-	Start() (*exec.Cmd, error)
+	//Start start app and return its running process
+	Start() (Process, error)
 
-	//Stop stop current execution of app
-	Stop(*exec.Cmd) error
+	//Stop stop the running process of app
+	Stop(p Process) error
 
-	//Restart restart a cmd passed in parameter
-	Restart(*exec.Cmd) error
+	//Rebuild compile the changed app while its process keeps running,
+	//so the next Start run the new version, aborting the build if ctx is done
+	Rebuild(ctx context.Context) error
 }
 
 //AppRunner struct to compile, start
 //and restart golang apps
 type AppRunner struct {
+	//name of the app, when many apps are watched
+	name string
+
 	// directory to watcher for changes
 	dir string
 
@@ -36,60 +58,398 @@ type AppRunner struct {
 	//flags to use in go build command
 	buildFlags []string
 
+	//main package to compile, a directory relative to dir or an import path,
+	//the package of dir is compiled when empty
+	pkg string
+
 	//compiled binary name to execute
 	binaryName string
+
+	//the binary was chosen by the user, so Clean keep it
+	keepBinary bool
+
+	//signal sent to ask the app to exit
+	stopSignal os.Signal
+
+	//time to wait for the app to exit before killing it
+	killTimeout time.Duration
+
+	//commands to run during the lifecycle of the app
+	hooks Hooks
+
+	//command template to compile the app instead of go build
+	buildCmd string
+
+	//command template to run the app instead of the compiled binary
+	runCmd string
+
+	//where to write the output of the commands instead of os.Stdout and os.Stderr
+	stdout, stderr io.Writer
+
+	//receive the logs of the app, the default logger if nil
+	logger Logger
+}
+
+//CommandData values available in the templates
+//of the build and run commands
+type CommandData struct {
+	//Output path of the binary to build or run
+	Output string
+
+	//Dir directory of the app
+	Dir string
+
+	//Package main package to compile
+	Package string
+}
+
+//Hooks commands run in the app directory during the lifecycle of the app,
+//each command is run by the shell in the order they are declared
+type Hooks struct {
+	//BeforeBuild run before compile the app, a failure abort the restart
+	BeforeBuild []string
+
+	//AfterBuild run after the app compiled successfully, a failure abort the restart
+	AfterBuild []string
+
+	//BeforeStart run before start the app, a failure abort the start
+	BeforeStart []string
+
+	//AfterStop run after the app stopped
+	AfterStop []string
+}
+
+func (h Hooks) empty() bool {
+	return len(h.BeforeBuild) == 0 && len(h.AfterBuild) == 0 && len(h.BeforeStart) == 0 && len(h.AfterStop) == 0
 }
 
-func (app AppRunner) Compile() error {
-	if _, err := os.Stat(app.binaryName); !os.IsNotExist(err) {
-		logrus.Debugf("Removing existing binary buildfile %s\n", app.binaryName)
-		if err := os.Remove(app.binaryName); err != nil {
-			return fmt.Errorf("error to remove existing binary: %v", err)
+func (app AppRunner) Compile(ctx context.Context) error {
+	for _, name := range []string{app.binaryName, app.newBinaryName()} {
+		binary := app.binaryPath(name)
+		if _, err := os.Stat(binary); !os.IsNotExist(err) {
+			app.log().Debugf("Removing existing binary buildfile %s\n", binary)
+			if err := os.Remove(binary); err != nil {
+				return fmt.Errorf("error to remove existing binary: %v", err)
+			}
 		}
 	}
-	buildFlags := []string{"build"}
-	buildFlags = append(buildFlags, app.buildFlags...)
-	return newCmd(app.dir, "go", buildFlags...).Run()
+	binary := app.binaryPath(app.binaryName)
+	if err := os.MkdirAll(filepath.Dir(binary), 0755); err != nil {
+		return fmt.Errorf("error to create binary directory: %v", err)
+	}
+	return app.build(ctx, app.binaryName)
 }
 
-func (app AppRunner) Start() (*exec.Cmd, error) {
-	cmd := cmdRunBinary(app.dir, app.binaryName, app.runFlags...)
-	if err := cmd.Start(); err != nil {
+//build run go build writing the binary to output,
+//with the before and after build hooks around it
+func (app AppRunner) build(ctx context.Context, output string) error {
+	if err := app.runHooks(ctx, app.hooks.BeforeBuild); err != nil {
+		return err
+	}
+	cmd, err := app.buildCommand(output)
+	if err != nil {
+		return err
+	}
+	var stderr bytes.Buffer
+	cmd.Stderr = io.MultiWriter(cmd.Stderr, &stderr)
+	if err := runProcessGroup(ctx, cmd); err != nil {
+		return &buildError{err: err, output: stderr.String()}
+	}
+	return app.runHooks(ctx, app.hooks.AfterBuild)
+}
+
+//buildError a failed build command and its error output
+type buildError struct {
+	err    error
+	output string
+}
+
+func (e *buildError) Error() string {
+	return e.err.Error()
+}
+
+func (e *buildError) Unwrap() error {
+	return e.err
+}
+
+//buildOutput return the error output of the failed build of err, if any
+func buildOutput(err error) string {
+	var build *buildError
+	if errors.As(err, &build) {
+		return build.output
+	}
+	return ""
+}
+
+//buildCommand return the command to compile the app into output
+func (app AppRunner) buildCommand(output string) (*exec.Cmd, error) {
+	if app.buildCmd == "" {
+		buildFlags := []string{"build", "-o", output}
+		buildFlags = append(buildFlags, app.buildFlags...)
+		if pkg := app.packagePath(); pkg != "" {
+			buildFlags = append(buildFlags, pkg)
+		}
+		return app.prepareCmd(newCmd(app.dir, "go", buildFlags...)), nil
+	}
+	command, err := renderCommand(app.buildCmd, app.commandData(output))
+	if err != nil {
 		return nil, err
 	}
+	shell, args := shellCommand(command)
+	return app.prepareCmd(newCmd(app.dir, shell, args...)), nil
+}
 
-	return cmd, nil
+//runCommand return the command to run the app
+func (app AppRunner) runCommand() (*exec.Cmd, error) {
+	if app.runCmd == "" {
+		return app.prepareCmd(cmdRunBinary(app.dir, app.binaryName, app.runFlags...)), nil
+	}
+	command, err := renderCommand(app.runCmd, app.commandData(app.binaryName))
+	if err != nil {
+		return nil, err
+	}
+	shell, args := shellCommand(command, app.runFlags...)
+	cmd := newCmd(app.dir, shell, args...)
+	setProcessGroup(cmd)
+	return app.prepareCmd(cmd), nil
+}
+
+//prepareCmd log cmd and write its output to the writers of the app, if any
+func (app AppRunner) prepareCmd(cmd *exec.Cmd) *exec.Cmd {
+	app.log().Debugf("Command: %v\n", cmd.Args)
+	if app.stdout != nil {
+		cmd.Stdout = app.stdout
+	}
+	if app.stderr != nil {
+		cmd.Stderr = app.stderr
+	}
+	return cmd
+}
+
+func (app AppRunner) commandData(output string) CommandData {
+	return CommandData{
+		Output:  app.binaryPath(output),
+		Dir:     app.dir,
+		Package: app.packagePath(),
+	}
 }
 
-func (app AppRunner) Stop(cmd *exec.Cmd) error {
-	logrus.Debugf("Killing current execution %d\n", cmd.Process.Pid)
-	if err := cmd.Process.Kill(); err != nil {
-		return fmt.Errorf("error to kill exiting process running: %v", err)
+//packagePath return the package to compile as go build expect it,
+//directories inside dir like cmd/server are prefixed with ./ so
+//they are not confused with import paths
+func (app AppRunner) packagePath() string {
+	if app.pkg == "" || filepath.IsAbs(app.pkg) || strings.HasPrefix(app.pkg, ".") {
+		return app.pkg
+	}
+	if info, err := os.Stat(filepath.Join(app.dir, app.pkg)); err == nil && info.IsDir() {
+		return "./" + filepath.ToSlash(app.pkg)
+	}
+	return app.pkg
+}
+
+//renderCommand execute the command template with data
+func renderCommand(command string, data CommandData) (string, error) {
+	tmpl, err := template.New("command").Parse(command)
+	if err != nil {
+		return "", fmt.Errorf("error to parse command %q: %v", command, err)
+	}
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, data); err != nil {
+		return "", fmt.Errorf("error to render command %q: %v", command, err)
+	}
+	return buf.String(), nil
+}
+
+//runHooks run each command in order, stopping at the first failure
+func (app AppRunner) runHooks(ctx context.Context, commands []string) error {
+	for _, command := range commands {
+		app.log().Debugf("Running hook: %s\n", command)
+		shell, args := shellCommand(command)
+		if err := runProcessGroup(ctx, app.prepareCmd(newCmd(app.dir, shell, args...))); err != nil {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			return fmt.Errorf("%w %q: %v", ErrCmdHook, command, err)
+		}
 	}
 	return nil
 }
 
-func (app AppRunner) Restart(cmd *exec.Cmd) error {
-	if err := app.Stop(cmd); err != nil {
-		return err
+func (app AppRunner) Start() (Process, error) {
+	// a custom build command may not write the binary
+	if _, err := os.Stat(app.binaryPath(app.newBinaryName())); err == nil {
+		if err := os.Rename(app.binaryPath(app.newBinaryName()), app.binaryPath(app.binaryName)); err != nil {
+			return nil, fmt.Errorf("error to replace binary: %v", err)
+		}
+	}
+	if err := app.runHooks(context.Background(), app.hooks.BeforeStart); err != nil {
+		return nil, err
+	}
+	cmd, err := app.runCommand()
+	if err != nil {
+		return nil, err
+	}
+	p, err := startProcess(cmd)
+	if err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+func (app AppRunner) Stop(p Process) error {
+	if p == nil {
+		return nil
 	}
-	logrus.Debugf("Recompiling...")
-	if err := app.Compile(); err != nil {
-		return ErrCmdCompile
+	done := processDone(p)
+	select {
+	case <-done:
+		app.log().Debugf("Process %d already exited\n", p.Pid())
+		app.afterStop()
+		return nil
+	default:
 	}
-	*cmd = *cmdRunBinary(app.dir, app.binaryName, app.runFlags...)
-	if err := cmd.Start(); err != nil {
-		return fmt.Errorf("error to start program: %v", err)
+	app.log().Debugf("Sending %v to process group %d\n", app.signal(), p.Pid())
+	if err := p.Signal(app.signal()); err != nil {
+		return fmt.Errorf("error to stop exiting process running: %v", err)
+	}
+
+	select {
+	case <-done:
+	case <-time.After(app.timeout()):
+		app.log().Debugf("Process %d did not exit after %v, killing it\n", p.Pid(), app.timeout())
+		if err := p.Signal(os.Kill); err != nil {
+			return fmt.Errorf("error to kill exiting process running: %v", err)
+		}
+		<-done
+	}
+
+	// make sure that no process started by the app is left behind
+	// holding resources needed by the next execution
+	_ = p.Signal(os.Kill)
+
+	app.afterStop()
+	return nil
+}
+
+//afterStop run the after stop hooks, whose failures are only logged
+func (app AppRunner) afterStop() {
+	if err := app.runHooks(context.Background(), app.hooks.AfterStop); err != nil {
+		app.log().Errorf("%v\n", err)
+	}
+}
+
+//Rebuild compile the app into a new binary, so the running app is
+//kept alive if the new code does not compile. Start replace the
+//binary of the app with the new one
+func (app AppRunner) Rebuild(ctx context.Context) error {
+	app.log().Debugf("Recompiling...")
+	if err := app.build(ctx, app.newBinaryName()); err != nil {
+		_ = os.Remove(app.binaryPath(app.newBinaryName()))
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		if errors.Is(err, ErrCmdHook) {
+			app.log().Errorf("%v\n", err)
+			return err
+		}
+		return &buildError{err: ErrCmdCompile, output: buildOutput(err)}
 	}
+	return nil
+}
 
+//Clean remove the compiled binaries of the app, except
+//the binary written where the user chose
+func (app AppRunner) Clean() error {
+	binaries := []string{app.newBinaryName()}
+	if !app.keepBinary {
+		binaries = append(binaries, app.binaryName)
+	}
+	for _, binary := range binaries {
+		if err := os.Remove(app.binaryPath(binary)); err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("error to remove binary: %v", err)
+		}
+	}
 	return nil
 }
 
+//newBinaryName return the name of the binary compiled by Rebuild
+func (app AppRunner) newBinaryName() string {
+	return app.binaryName + ".new"
+}
+
+//binaryPath return the path of binary relative to the current directory
+func (app AppRunner) binaryPath(binary string) string {
+	if filepath.IsAbs(binary) {
+		return binary
+	}
+	return filepath.Join(app.dir, binary)
+}
+
+//log return the logger of the app, with its name when it has one
+func (app AppRunner) log() Logger {
+	logger := app.logger
+	if logger == nil {
+		logger = defaultLogger
+	}
+	if app.name == "" {
+		return logger
+	}
+	return logger.With("app", app.name)
+}
+
+func (app AppRunner) signal() os.Signal {
+	if app.stopSignal == nil {
+		return DefaultStopSignal
+	}
+	return app.stopSignal
+}
+
+func (app AppRunner) timeout() time.Duration {
+	if app.killTimeout <= 0 {
+		return DefaultKillTimeout
+	}
+	return app.killTimeout
+}
+
+//defaultBinaryPath return where to write the binary compiled from pkg of dir,
+//inside the user cache directory so the watched directory is not changed
+func defaultBinaryPath(dir, pkg string) string {
+	cacheDir, err := os.UserCacheDir()
+	if err != nil {
+		cacheDir = os.TempDir()
+	}
+	if absDir, err := filepath.Abs(dir); err == nil {
+		dir = absDir
+	}
+	name := getCurrentFolderName(dir)
+	if base := path.Base(filepath.ToSlash(pkg)); pkg != "" && base != "." && base != "/" {
+		name = base
+	}
+	// folders with the same name in different paths must not share a binary
+	hash := fnv.New32a()
+	_, _ = hash.Write([]byte(dir))
+	_, _ = hash.Write([]byte(pkg))
+	return filepath.Join(cacheDir, "gowatch", fmt.Sprintf("%s-%x", name, hash.Sum32()), executableName(name))
+}
+
+//executableName return the file name of the binary called name,
+//with the .exe extension windows need to find it when run
+func executableName(name string) string {
+	if runtime.GOOS == "windows" {
+		return name + ".exe"
+	}
+	return name
+}
+
 func cmdRunBinary(dir, binaryName string, args ...string) *exec.Cmd {
-	if strings.HasPrefix(binaryName, "/") {
-		return newCmd(dir, binaryName, args...)
+	var cmd *exec.Cmd
+	if filepath.IsAbs(binaryName) {
+		cmd = newCmd(dir, binaryName, args...)
+	} else {
+		cmd = newCmd(dir, fmt.Sprintf("./%s", binaryName), args...)
 	}
-	return newCmd(dir, fmt.Sprintf("./%s", binaryName), args...)
+	setProcessGroup(cmd)
+	return cmd
 }
 
 func newCmd(dir, command string, args ...string) *exec.Cmd {
@@ -98,6 +458,5 @@ func newCmd(dir, command string, args ...string) *exec.Cmd {
 	cmd.Stdin = os.Stdin
 	cmd.Stdout = os.Stdout
 	cmd.Stderr = os.Stderr
-	logrus.Debugf("Command: %v\n", cmd.Args)
 	return cmd
 }
@@ -1,9 +1,17 @@
 package gowatch
 
 import (
+	"context"
+	"errors"
 	"fmt"
+	"io/ioutil"
 	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"syscall"
 	"testing"
+	"time"
 )
 
 func TestStartApp(t *testing.T) {
@@ -16,7 +24,7 @@ func TestStartApp(t *testing.T) {
 		dir:        fmt.Sprintf("%s/testdata/helloworld", pwd),
 		binaryName: fmt.Sprintf("%s/testdata/helloworld/helloworld", pwd),
 	}
-	if err := w.Compile(); err != nil {
+	if err := w.Compile(context.Background()); err != nil {
 		t.Fatal(err)
 	}
 	if _, err := w.Start(); err != nil {
@@ -33,7 +41,7 @@ func TestCompileApp(t *testing.T) {
 		dir:        fmt.Sprintf("%s/testdata/helloworld", pwd),
 		binaryName: fmt.Sprintf("%s/testdata/helloworld/helloworld", pwd),
 	}
-	if err := w.Compile(); err != nil {
+	if err := w.Compile(context.Background()); err != nil {
 		t.Errorf(unexpectedErrorMsg, err)
 	}
 }
@@ -47,30 +55,406 @@ func TestCompileAppWithFlags(t *testing.T) {
 		binaryName: fmt.Sprintf("%s/testdata/helloworld/helloworld", pwd),
 		buildFlags: []string{"-x", "-v"},
 	}
-	if err := w.Compile(); err != nil {
+	if err := w.Compile(context.Background()); err != nil {
 		t.Errorf(unexpectedErrorMsg, err)
 	}
 }
 
-func TestRestartApp(t *testing.T) {
+func TestRebuildApp(t *testing.T) {
 	w := AppRunner{
 		binaryName: "http-server",
 		dir:        "./testdata/http-server",
 	}
-	if err := w.Compile(); err != nil {
+	if err := w.Compile(context.Background()); err != nil {
 		t.Fatal(err)
 	}
 
-	cmd := cmdRunBinary(w.dir, w.binaryName, w.runFlags...)
-	if err := cmd.Start(); err != nil {
+	p, err := w.Start()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := w.Rebuild(context.Background()); err != nil {
+		t.Fatalf(unexpectedErrorMsg, err)
+	}
+	if p.(*runningProcess).exited() {
+		t.Error("running process should be kept by the rebuild")
+	}
+	if err := w.Stop(p); err != nil {
+		t.Fatalf(unexpectedErrorMsg, err)
+	}
+	next, err := w.Start()
+	if err != nil {
+		t.Fatalf(unexpectedErrorMsg, err)
+	}
+	if err := next.Signal(os.Kill); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(w.binaryPath(w.newBinaryName())); !os.IsNotExist(err) {
+		t.Errorf("rebuilt binary should replace the binary of the app: %v", err)
+	}
+}
+
+func TestCompileAppCanceled(t *testing.T) {
+	w := AppRunner{dir: "./testdata/helloworld"}
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if err := w.Compile(ctx); err == nil {
+		t.Error("expected error to compile with canceled context")
+	}
+}
+
+func TestRebuildAppCanceled(t *testing.T) {
+	w := AppRunner{dir: "./testdata/helloworld", binaryName: "helloworld"}
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if err := w.Rebuild(ctx); err != context.Canceled {
+		t.Errorf(assertErrorMsg, context.Canceled, err)
+	}
+}
+
+func TestRebuildAppCompileError(t *testing.T) {
+	dir, err := ioutil.TempDir("", "TestRebuildAppCompileError")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	for name, content := range map[string]string{
+		"go.mod":  "module broken\n",
+		"main.go": "package main\n\nfunc main() {",
+	} {
+		if err := ioutil.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	w := AppRunner{dir: dir, binaryName: "broken"}
+	if err := w.Rebuild(context.Background()); !errors.Is(err, ErrCmdCompile) {
+		t.Fatalf(assertErrorMsg, ErrCmdCompile, err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "broken.new")); !os.IsNotExist(err) {
+		t.Errorf("failed build should be removed: %v", err)
+	}
+}
+
+func TestStopApp(t *testing.T) {
+	w := AppRunner{killTimeout: 5 * time.Second}
+
+	p, err := startProcess(newCmd(".", "sleep", "30"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	start := time.Now()
+	if err := w.Stop(p); err != nil {
+		t.Fatalf(unexpectedErrorMsg, err)
+	}
+	if elapsed := time.Since(start); elapsed >= w.killTimeout {
+		t.Errorf("process should exit on %v before kill timeout: %v", DefaultStopSignal, elapsed)
+	}
+	status := p.state.Sys().(syscall.WaitStatus)
+	if status.Signal() != syscall.SIGTERM {
+		t.Errorf(assertErrorMsg, syscall.SIGTERM, status.Signal())
+	}
+}
+
+func TestStopAppKillTimeout(t *testing.T) {
+	w := AppRunner{
+		stopSignal:  syscall.SIGINT,
+		killTimeout: 500 * time.Millisecond,
+	}
+
+	p, err := startProcess(newCmd(".", "sh", "-c", `trap "" INT; exec sleep 30`))
+	if err != nil {
 		t.Fatal(err)
 	}
-	if err := w.Restart(cmd); err != nil {
+	// give some time to the shell install the trap
+	time.Sleep(100 * time.Millisecond)
+	if err := w.Stop(p); err != nil {
 		t.Fatalf(unexpectedErrorMsg, err)
 	}
-	if err := cmd.Process.Kill(); err != nil {
+	status := p.state.Sys().(syscall.WaitStatus)
+	if status.Signal() != syscall.SIGKILL {
+		t.Errorf(assertErrorMsg, syscall.SIGKILL, status.Signal())
+	}
+}
+
+func TestAppHooks(t *testing.T) {
+	dir, err := ioutil.TempDir("", "TestAppHooks")
+	if err != nil {
 		t.Fatal(err)
 	}
+	defer os.RemoveAll(dir)
+	logFile := filepath.Join(dir, "hooks.log")
+	hook := func(name string) []string {
+		return []string{fmt.Sprintf("echo %s >> %s", name, logFile)}
+	}
+
+	w := AppRunner{
+		dir:        "./testdata/helloworld",
+		binaryName: filepath.Join(dir, "helloworld"),
+		hooks: Hooks{
+			BeforeBuild: hook("before_build"),
+			AfterBuild:  hook("after_build"),
+			BeforeStart: hook("before_start"),
+			AfterStop:   hook("after_stop"),
+		},
+	}
+	if err := w.Compile(context.Background()); err != nil {
+		t.Fatalf(unexpectedErrorMsg, err)
+	}
+	p, err := w.Start()
+	if err != nil {
+		t.Fatalf(unexpectedErrorMsg, err)
+	}
+	if err := w.Stop(p); err != nil {
+		t.Fatalf(unexpectedErrorMsg, err)
+	}
+
+	data, err := ioutil.ReadFile(logFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := "before_build\nafter_build\nbefore_start\nafter_stop\n"
+	if string(data) != expected {
+		t.Errorf(assertErrorMsg, expected, string(data))
+	}
+}
+
+func TestStopExitedAppHooks(t *testing.T) {
+	dir, err := ioutil.TempDir("", "TestStopExitedAppHooks")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	logFile := filepath.Join(dir, "hooks.log")
+
+	w := AppRunner{dir: dir, hooks: Hooks{AfterStop: []string{"echo after_stop >> " + logFile}}}
+	p, err := startProcess(newCmd(dir, "true"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := p.Wait(); err != nil {
+		t.Fatal(err)
+	}
+	if err := w.Stop(p); err != nil {
+		t.Fatalf(unexpectedErrorMsg, err)
+	}
+	data, err := ioutil.ReadFile(logFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != "after_stop\n" {
+		t.Errorf(assertErrorMsg, "after_stop\n", string(data))
+	}
+}
+
+func TestRebuildAppHookError(t *testing.T) {
+	w := AppRunner{
+		dir:        "./testdata/helloworld",
+		binaryName: "helloworld",
+		hooks:      Hooks{BeforeBuild: []string{"exit 1"}},
+	}
+	if err := w.Rebuild(context.Background()); !errors.Is(err, ErrCmdHook) {
+		t.Fatalf(assertErrorMsg, ErrCmdHook, err)
+	}
+}
+
+func TestAppCustomCommands(t *testing.T) {
+	dir, err := ioutil.TempDir("", "TestAppCustomCommands")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	logFile := filepath.Join(dir, "run.log")
+
+	w := AppRunner{
+		dir:        "./testdata/helloworld",
+		binaryName: filepath.Join(dir, "helloworld"),
+		runFlags:   []string{"arg1", "arg2"},
+		buildCmd:   "go build -o {{.Output}} .",
+		runCmd:     fmt.Sprintf("{{.Output}} > %s", logFile),
+	}
+	if err := w.Compile(context.Background()); err != nil {
+		t.Fatalf(unexpectedErrorMsg, err)
+	}
+	if _, err := os.Stat(w.binaryName); err != nil {
+		t.Fatalf("binary should be built by the custom command: %v", err)
+	}
+	p, err := w.Start()
+	if err != nil {
+		t.Fatalf(unexpectedErrorMsg, err)
+	}
+	if err := p.Wait(); err != nil {
+		t.Fatalf(unexpectedErrorMsg, err)
+	}
+
+	data, err := ioutil.ReadFile(logFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(data), "args: [arg1 arg2]") {
+		t.Errorf(assertErrorMsg, "args: [arg1 arg2]", string(data))
+	}
+}
+
+func TestCompileAppCustomCommandError(t *testing.T) {
+	w := AppRunner{
+		dir:        "./testdata/helloworld",
+		binaryName: "helloworld",
+		buildCmd:   "exit 1",
+	}
+	if err := w.Compile(context.Background()); err == nil {
+		t.Error("expected error from a failing build command")
+	}
+}
+
+func TestCompileAppPackage(t *testing.T) {
+	dir, err := ioutil.TempDir("", "TestCompileAppPackage")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	for _, pkg := range []string{"cmd/server", "./cmd/server", "gowatch/testdata/multiapp/cmd/server"} {
+		w := AppRunner{
+			dir:        "./testdata/multiapp",
+			binaryName: filepath.Join(dir, "server"),
+			pkg:        pkg,
+		}
+		if err := w.Compile(context.Background()); err != nil {
+			t.Fatalf("error to compile package %s: %v", pkg, err)
+		}
+		if _, err := os.Stat(w.binaryName); err != nil {
+			t.Errorf("binary of package %s should be built: %v", pkg, err)
+		}
+	}
+}
+
+func TestPackagePath(t *testing.T) {
+	tests := []struct {
+		pkg      string
+		expected string
+	}{
+		{"", ""},
+		{"cmd/server", "./cmd/server"},
+		{"./cmd/server", "./cmd/server"},
+		{"../multiapp/cmd/server", "../multiapp/cmd/server"},
+		{"github.com/foo/bar/cmd/server", "github.com/foo/bar/cmd/server"},
+	}
+	for _, test := range tests {
+		app := AppRunner{dir: "./testdata/multiapp", pkg: test.pkg}
+		if pkg := app.packagePath(); pkg != test.expected {
+			t.Errorf(assertErrorMsg, test.expected, pkg)
+		}
+	}
+}
+
+func TestRenderCommand(t *testing.T) {
+	command, err := renderCommand("go build -o {{.Output}} {{.Dir}}/cmd/api", CommandData{Output: "/tmp/api", Dir: "/src"})
+	if err != nil {
+		t.Fatalf(unexpectedErrorMsg, err)
+	}
+	expected := "go build -o /tmp/api /src/cmd/api"
+	if command != expected {
+		t.Errorf(assertErrorMsg, expected, command)
+	}
+
+	if _, err := renderCommand("go build -o {{.Output", CommandData{}); err == nil {
+		t.Error("expected error to parse an invalid template")
+	}
+	if _, err := renderCommand("{{.Unknown}}", CommandData{}); err == nil {
+		t.Error("expected error to render an unknown field")
+	}
+}
+
+func TestCleanApp(t *testing.T) {
+	dir, err := ioutil.TempDir("", "TestCleanApp")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	w := AppRunner{dir: dir, binaryName: "bin/app"}
+	if err := os.MkdirAll(filepath.Join(dir, "bin"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	for _, binary := range []string{"bin/app", "bin/app.new"} {
+		if err := ioutil.WriteFile(filepath.Join(dir, binary), nil, 0755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := w.Clean(); err != nil {
+		t.Fatalf(unexpectedErrorMsg, err)
+	}
+	for _, binary := range []string{"bin/app", "bin/app.new"} {
+		if _, err := os.Stat(filepath.Join(dir, binary)); !os.IsNotExist(err) {
+			t.Errorf("%s should be removed: %v", binary, err)
+		}
+	}
+	// nothing to remove
+	if err := w.Clean(); err != nil {
+		t.Errorf(unexpectedErrorMsg, err)
+	}
+}
+
+func TestCleanAppKeepBinary(t *testing.T) {
+	dir, err := ioutil.TempDir("", "TestCleanAppKeepBinary")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	w := AppRunner{dir: dir, binaryName: "app", keepBinary: true}
+	for _, binary := range []string{"app", "app.new"} {
+		if err := ioutil.WriteFile(filepath.Join(dir, binary), nil, 0755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := w.Clean(); err != nil {
+		t.Fatalf(unexpectedErrorMsg, err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "app")); err != nil {
+		t.Errorf("binary chosen by the user should be kept: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "app.new")); !os.IsNotExist(err) {
+		t.Errorf("app.new should be removed: %v", err)
+	}
+}
+
+func TestDefaultBinaryPath(t *testing.T) {
+	binary := defaultBinaryPath("/home/unittest/gowatch/testcase", "")
+	if !filepath.IsAbs(binary) {
+		t.Errorf("binary path should be absolute: %s", binary)
+	}
+	if filepath.Base(binary) != executableName("testcase") {
+		t.Errorf(assertErrorMsg, executableName("testcase"), filepath.Base(binary))
+	}
+	if strings.HasPrefix(binary, "/home/unittest/gowatch/testcase") {
+		t.Errorf("binary should not be written in the watched directory: %s", binary)
+	}
+	if other := defaultBinaryPath("/home/unittest/other/testcase", ""); other == binary {
+		t.Errorf("directories with same name should not share binary: %s", binary)
+	}
+}
+
+func TestDefaultBinaryPathPackage(t *testing.T) {
+	binary := defaultBinaryPath("/home/unittest/gowatch/testcase", "./cmd/server")
+	if filepath.Base(binary) != executableName("server") {
+		t.Errorf(assertErrorMsg, executableName("server"), filepath.Base(binary))
+	}
+	if other := defaultBinaryPath("/home/unittest/gowatch/testcase", "./cmd/worker"); filepath.Dir(other) == filepath.Dir(binary) {
+		t.Errorf("packages of same directory should not share binary: %s", binary)
+	}
+	if binary := defaultBinaryPath("/home/unittest/gowatch/testcase", "."); filepath.Base(binary) != executableName("testcase") {
+		t.Errorf(assertErrorMsg, executableName("testcase"), filepath.Base(binary))
+	}
+}
+
+func TestExecutableName(t *testing.T) {
+	expected := "server"
+	if runtime.GOOS == "windows" {
+		expected = "server.exe"
+	}
+	if name := executableName("server"); name != expected {
+		t.Errorf(assertErrorMsg, expected, name)
+	}
 }
 
 func TestCmdRunBinary(t *testing.T) {
@@ -2,16 +2,61 @@ package config
 
 import (
 	"io/ioutil"
+	"time"
 
 	"gopkg.in/yaml.v2"
 )
 
 type Config struct {
-	Dir        string   `yaml:"dir"`
-	Buildflags []string `yaml:"build_flags"`
-	RunFlags   []string `yaml:"run_flags"`
-	Ignore     []string `yamll:"ignore"`
-	Verbose    bool     `yaml:"verbose"`
+	Dir          string         `yaml:"dir"`
+	Buildflags   []string       `yaml:"build_flags"`
+	RunFlags     []string       `yaml:"run_flags"`
+	Ignore       []string       `yamll:"ignore"`
+	Verbose      bool           `yaml:"verbose"`
+	StopSignal   string         `yaml:"stop_signal"`
+	KillTimeout  time.Duration  `yaml:"kill_timeout"`
+	Debounce     *time.Duration `yaml:"debounce"`
+	Output       string         `yaml:"output"`
+	Extensions   []string       `yaml:"extensions"`
+	Include      []string       `yaml:"include"`
+	Gitignore    *bool          `yaml:"gitignore"`
+	ExcludeDirs  []string       `yaml:"watch_exclude_dirs"`
+	Poll         bool           `yaml:"poll"`
+	PollInterval time.Duration  `yaml:"poll_interval"`
+	BeforeBuild  []string       `yaml:"before_build"`
+	AfterBuild   []string       `yaml:"after_build"`
+	BeforeStart  []string       `yaml:"before_start"`
+	AfterStop    []string       `yaml:"after_stop"`
+	BuildCmd     string         `yaml:"build_cmd"`
+	RunCmd       string         `yaml:"run_cmd"`
+	Package      string         `yaml:"package"`
+	Apps         []App          `yaml:"apps"`
+	Procfile     string         `yaml:"procfile"`
+	Processes    []Process      `yaml:"processes"`
+	Restart      string         `yaml:"restart"`
+	MaxRetries   int            `yaml:"max_retries"`
+}
+
+//Process an auxiliary process run alongside the apps
+type Process struct {
+	Name    string `yaml:"name"`
+	Command string `yaml:"command"`
+}
+
+//App settings of one of many apps watched at once
+type App struct {
+	Name        string   `yaml:"name"`
+	Package     string   `yaml:"package"`
+	Buildflags  []string `yaml:"build_flags"`
+	RunFlags    []string `yaml:"run_flags"`
+	Ignore      []string `yaml:"ignore"`
+	Output      string   `yaml:"output"`
+	BuildCmd    string   `yaml:"build_cmd"`
+	RunCmd      string   `yaml:"run_cmd"`
+	BeforeBuild []string `yaml:"before_build"`
+	AfterBuild  []string `yaml:"after_build"`
+	BeforeStart []string `yaml:"before_start"`
+	AfterStop   []string `yaml:"after_stop"`
 }
 
 func loadYmlConfig(cfg *Config, ymlFile string) error {
@@ -2,6 +2,7 @@ package config
 
 import (
 	"testing"
+	"time"
 )
 
 var (
@@ -34,6 +35,101 @@ func TestLoadYml(t *testing.T) {
 	if len(cfg.RunFlags) != 2 {
 		t.Errorf(keyDontLoadedCorrectlyMsg, "run_flags")
 	}
+
+	if cfg.StopSignal != "SIGINT" {
+		t.Errorf(keyDontLoadedCorrectlyMsg, "stop_signal")
+	}
+
+	if cfg.KillTimeout != 10*time.Second {
+		t.Errorf(keyDontLoadedCorrectlyMsg, "kill_timeout")
+	}
+
+	if cfg.Debounce == nil || *cfg.Debounce != 300*time.Millisecond {
+		t.Errorf(keyDontLoadedCorrectlyMsg, "debounce")
+	}
+
+	if cfg.Output != "./bin/app" {
+		t.Errorf(keyDontLoadedCorrectlyMsg, "output")
+	}
+
+	if len(cfg.Extensions) != 2 {
+		t.Errorf(keyDontLoadedCorrectlyMsg, "extensions")
+	}
+
+	if len(cfg.Include) != 1 {
+		t.Errorf(keyDontLoadedCorrectlyMsg, "include")
+	}
+
+	if cfg.Gitignore == nil || *cfg.Gitignore {
+		t.Errorf(keyDontLoadedCorrectlyMsg, "gitignore")
+	}
+
+	if len(cfg.ExcludeDirs) != 2 {
+		t.Errorf(keyDontLoadedCorrectlyMsg, "watch_exclude_dirs")
+	}
+
+	if !cfg.Poll {
+		t.Errorf(keyDontLoadedCorrectlyMsg, "poll")
+	}
+
+	if cfg.PollInterval != time.Second {
+		t.Errorf(keyDontLoadedCorrectlyMsg, "poll_interval")
+	}
+
+	if len(cfg.BeforeBuild) != 2 || cfg.BeforeBuild[0] != "go generate ./..." {
+		t.Errorf(keyDontLoadedCorrectlyMsg, "before_build")
+	}
+
+	if len(cfg.AfterBuild) != 1 {
+		t.Errorf(keyDontLoadedCorrectlyMsg, "after_build")
+	}
+
+	if len(cfg.BeforeStart) != 1 {
+		t.Errorf(keyDontLoadedCorrectlyMsg, "before_start")
+	}
+
+	if len(cfg.AfterStop) != 1 {
+		t.Errorf(keyDontLoadedCorrectlyMsg, "after_stop")
+	}
+
+	if cfg.BuildCmd != "go build -o {{.Output}} ./cmd/api" {
+		t.Errorf(keyDontLoadedCorrectlyMsg, "build_cmd")
+	}
+
+	if cfg.RunCmd != "{{.Output}} serve" {
+		t.Errorf(keyDontLoadedCorrectlyMsg, "run_cmd")
+	}
+
+	if cfg.Package != "./cmd/server" {
+		t.Errorf(keyDontLoadedCorrectlyMsg, "package")
+	}
+
+	if len(cfg.Apps) != 2 {
+		t.Fatalf(keyDontLoadedCorrectlyMsg, "apps")
+	}
+	if api := cfg.Apps[0]; api.Name != "api" || api.Package != "./cmd/api" || len(api.RunFlags) != 2 {
+		t.Errorf(keyDontLoadedCorrectlyMsg, "apps.api")
+	}
+	if worker := cfg.Apps[1]; worker.Name != "worker" || len(worker.Ignore) != 1 ||
+		worker.Output != "./bin/worker" || len(worker.BeforeBuild) != 1 {
+		t.Errorf(keyDontLoadedCorrectlyMsg, "apps.worker")
+	}
+
+	if cfg.Procfile != "Procfile.dev" {
+		t.Errorf(keyDontLoadedCorrectlyMsg, "procfile")
+	}
+
+	if len(cfg.Processes) != 1 || cfg.Processes[0].Name != "stub" || cfg.Processes[0].Command != "go run ./stub" {
+		t.Errorf(keyDontLoadedCorrectlyMsg, "processes")
+	}
+
+	if cfg.Restart != "on-failure" {
+		t.Errorf(keyDontLoadedCorrectlyMsg, "restart")
+	}
+
+	if cfg.MaxRetries != 5 {
+		t.Errorf(keyDontLoadedCorrectlyMsg, "max_retries")
+	}
 }
 
 func TestLoadYmlConfig(t *testing.T) {
@@ -1,10 +1,14 @@
 package main
 
 import (
+	"context"
 	"fmt"
 	"os"
+	"os/signal"
 	"path/filepath"
 	"strings"
+	"syscall"
+	"time"
 
 	"github.com/msalcantara/gowatch"
 	"github.com/msalcantara/gowatch/cmd/gowatch/config"
@@ -20,12 +24,29 @@ func main() {
 		os.Exit(2)
 	}
 
-	w, err := gowatch.NewWatcher(cfg.Dir, cfg.Buildflags, cfg.RunFlags, cfg.Ignore)
+	opts, err := watcherOptions(cfg)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, errors.Wrapf(err, "Error parsing commandline arguments"))
+		os.Exit(2)
+	}
+
+	w, err := gowatch.New(cfg.Dir, opts...)
 	if err != nil {
 		fmt.Fprintln(os.Stderr, errors.Wrapf(err, "Error to create new watcher"))
 		os.Exit(2)
 	}
-	if err := w.Run(); err != nil {
+
+	// the app run in its own process group, so it does not receive
+	// the signals of the terminal, stop it before exiting
+	ctx, cancel := context.WithCancel(context.Background())
+	sigs := make(chan os.Signal, 1)
+	signal.Notify(sigs, os.Interrupt, syscall.SIGTERM)
+	go func() {
+		<-sigs
+		cancel()
+	}()
+
+	if err := w.Run(ctx); err != nil {
 		fmt.Fprintln(os.Stderr, errors.Wrapf(err, "Error to start gowatch"))
 		os.Exit(2)
 	}
@@ -39,6 +60,22 @@ func cli(args []string) (config.Config, error) {
 		runArgs                          []string
 		dirFlag                          string
 		verboseFlag                      bool
+		stopSignalFlag                   string
+		killTimeoutFlag                  time.Duration
+		debounceFlag                     time.Duration
+		debounceSet                      bool
+		outputFlag                       string
+		extensionsFlag, includeFlag      string
+		gitignoreFlag, gitignoreSet      bool
+		excludeDirsFlag                  string
+		pollFlag                         bool
+		pollIntervalFlag                 time.Duration
+		buildCmdFlag, runCmdFlag         string
+		packageFlag                      string
+		procfileFlag                     string
+		restartFlag                      string
+		maxRetriesFlag                   int
+		maxRetriesSet                    bool
 	)
 
 	{
@@ -53,10 +90,51 @@ func cli(args []string) (config.Config, error) {
 
 		a.Flag("run-flags", "custon args to your app").StringVar(&runFlags)
 
+		a.Flag("package", "main package to compile, like ./cmd/server (default the watched directory)").Short('p').StringVar(&packageFlag)
+
+		a.Flag("build-cmd", "command to compile your app instead of go build, like 'go build -o {{.Output}} ./cmd/api'").StringVar(&buildCmdFlag)
+
+		a.Flag("run-cmd", "command to run your app instead of the compiled binary, like '{{.Output}} serve'").StringVar(&runCmdFlag)
+
+		a.Flag("procfile", "Procfile with auxiliary processes to run alongside your app").StringVar(&procfileFlag)
+
 		a.Flag("dir", "directory to wath .go files").Short('d').Default(".").StringVar(&dirFlag)
 
 		a.Flag("ignore", "pattern of files to not watch").Short('i').StringVar(&ignoreFlag)
 
+		a.Flag("ext", "extensions of files to watch (default .go)").Short('e').StringVar(&extensionsFlag)
+
+		a.Flag("include", "pattern of files to watch besides the extensions").StringVar(&includeFlag)
+
+		a.Flag("gitignore", "ignore files listed in .gitignore files (default true)").Action(func(*kingpin.ParseContext) error {
+			gitignoreSet = true
+			return nil
+		}).BoolVar(&gitignoreFlag)
+
+		a.Flag("exclude-dirs", "name pattern of directories to not watch (default .git,vendor,node_modules,.*)").StringVar(&excludeDirsFlag)
+
+		a.Flag("poll", "poll for changes instead of using file system notifications").BoolVar(&pollFlag)
+
+		a.Flag("poll-interval", "time between checks for changes when polling (default 500ms)").DurationVar(&pollIntervalFlag)
+
+		a.Flag("stop-signal", "signal sent to your app to stop it (default SIGTERM)").StringVar(&stopSignalFlag)
+
+		a.Flag("kill-timeout", "time to wait for your app to exit before killing it (default 5s)").DurationVar(&killTimeoutFlag)
+
+		a.Flag("debounce", "time to wait for more changes before restarting your app, 0 to restart on each change (default 100ms)").Action(func(*kingpin.ParseContext) error {
+			debounceSet = true
+			return nil
+		}).DurationVar(&debounceFlag)
+
+		a.Flag("output", "path of the compiled binary (default in the user cache directory)").Short('o').StringVar(&outputFlag)
+
+		a.Flag("restart", "restart your app when it exit by itself: never, on-failure or always (default never)").StringVar(&restartFlag)
+
+		a.Flag("max-retries", "times to restart your app after it crash before waiting for changes, 0 for unlimited (default unlimited)").Action(func(*kingpin.ParseContext) error {
+			maxRetriesSet = true
+			return nil
+		}).IntVar(&maxRetriesFlag)
+
 		a.Flag("verbose", "verbose mode").Short('V').BoolVar(&verboseFlag)
 
 		a.Arg("your-args", "custon args to your app").StringsVar(&runArgs)
@@ -82,10 +160,67 @@ func cli(args []string) (config.Config, error) {
 		cfg.Buildflags = strings.Split(buildFlags, ",")
 	}
 
+	if len(packageFlag) != 0 {
+		cfg.Package = packageFlag
+	}
+
+	if len(buildCmdFlag) != 0 {
+		cfg.BuildCmd = buildCmdFlag
+	}
+	if len(runCmdFlag) != 0 {
+		cfg.RunCmd = runCmdFlag
+	}
+
+	if len(procfileFlag) != 0 {
+		cfg.Procfile = procfileFlag
+	}
+
 	if len(ignoreFlag) != 0 {
 		cfg.Ignore = strings.Split(ignoreFlag, ",")
 	}
 
+	if len(extensionsFlag) != 0 {
+		cfg.Extensions = strings.Split(extensionsFlag, ",")
+	}
+
+	if len(includeFlag) != 0 {
+		cfg.Include = strings.Split(includeFlag, ",")
+	}
+
+	if gitignoreSet {
+		cfg.Gitignore = &gitignoreFlag
+	}
+
+	if len(excludeDirsFlag) != 0 {
+		cfg.ExcludeDirs = strings.Split(excludeDirsFlag, ",")
+	}
+
+	if pollFlag {
+		cfg.Poll = pollFlag
+	}
+	if pollIntervalFlag != 0 {
+		cfg.PollInterval = pollIntervalFlag
+	}
+
+	if len(stopSignalFlag) != 0 {
+		cfg.StopSignal = stopSignalFlag
+	}
+	if killTimeoutFlag != 0 {
+		cfg.KillTimeout = killTimeoutFlag
+	}
+	if debounceSet {
+		cfg.Debounce = &debounceFlag
+	}
+	if len(outputFlag) != 0 {
+		cfg.Output = outputFlag
+	}
+	if len(restartFlag) != 0 {
+		cfg.Restart = restartFlag
+	}
+	if maxRetriesSet {
+		cfg.MaxRetries = maxRetriesFlag
+	}
+
 	cfg.Dir = dirFlag
 	if cfg.Dir == "" || cfg.Dir == "." {
 		pwd, err := os.Getwd()
@@ -97,14 +232,139 @@ func cli(args []string) (config.Config, error) {
 	if verboseFlag {
 		cfg.Verbose = verboseFlag
 	}
+	return cfg, nil
+}
+
+//newLogger create the logger of gowatch, that log the
+//debug messages to the standard output in verbose mode
+func newLogger(cfg config.Config) *logrus.Logger {
+	logger := logrus.New()
 	if cfg.Verbose {
-		logrus.SetOutput(os.Stdout)
-		logrus.SetFormatter(&logrus.TextFormatter{
+		logger.SetOutput(os.Stdout)
+		logger.SetFormatter(&logrus.TextFormatter{
 			FullTimestamp:   true,
 			TimestampFormat: "2006-01-02T15:04:05",
 		})
-		logrus.SetLevel(logrus.DebugLevel)
+		logger.SetLevel(logrus.DebugLevel)
+	}
+	return logger
+}
 
+//watcherOptions convert cfg into the options of the watcher
+func watcherOptions(cfg config.Config) ([]gowatch.Option, error) {
+	stopSignal, err := parseSignal(cfg.StopSignal)
+	if err != nil {
+		return nil, err
 	}
-	return cfg, nil
+	restartPolicy, err := gowatch.ParseRestartPolicy(cfg.Restart)
+	if err != nil {
+		return nil, err
+	}
+	opts := []gowatch.Option{
+		gowatch.WithBuildFlags(cfg.Buildflags...),
+		gowatch.WithRunFlags(cfg.RunFlags...),
+		gowatch.WithIgnore(cfg.Ignore...),
+		gowatch.WithLogger(gowatch.NewLogrusLogger(newLogger(cfg))),
+		gowatch.WithStopSignal(stopSignal),
+		gowatch.WithKillTimeout(cfg.KillTimeout),
+		gowatch.WithOutput(cfg.Output),
+		gowatch.WithExtensions(cfg.Extensions...),
+		gowatch.WithInclude(cfg.Include...),
+		gowatch.WithGitignore(cfg.Gitignore == nil || *cfg.Gitignore),
+		gowatch.WithHooks(gowatch.Hooks{
+			BeforeBuild: cfg.BeforeBuild,
+			AfterBuild:  cfg.AfterBuild,
+			BeforeStart: cfg.BeforeStart,
+			AfterStop:   cfg.AfterStop,
+		}),
+		gowatch.WithPackage(cfg.Package),
+		gowatch.WithBuildCmd(cfg.BuildCmd),
+		gowatch.WithRunCmd(cfg.RunCmd),
+		gowatch.WithRestartPolicy(restartPolicy, cfg.MaxRetries),
+	}
+	if cfg.ExcludeDirs != nil {
+		opts = append(opts, gowatch.WithExcludeDirs(cfg.ExcludeDirs...))
+	}
+	if cfg.Debounce != nil {
+		opts = append(opts, gowatch.WithDebounce(*cfg.Debounce))
+	}
+	if cfg.Poll {
+		opts = append(opts, gowatch.WithPolling(cfg.PollInterval))
+	}
+	if len(cfg.Apps) != 0 {
+		opts = append(opts, gowatch.WithApps(appConfigs(cfg.Apps)...))
+	}
+	processes, err := processConfigs(cfg)
+	if err != nil {
+		return nil, err
+	}
+	if len(processes) != 0 {
+		opts = append(opts, gowatch.WithProcesses(processes...))
+	}
+	return opts, nil
+}
+
+//processConfigs return the auxiliary processes of the Procfile
+//and of the config file
+func processConfigs(cfg config.Config) ([]gowatch.ProcessConfig, error) {
+	var processes []gowatch.ProcessConfig
+	if cfg.Procfile != "" {
+		procfile, err := gowatch.LoadProcfile(cfg.Procfile)
+		if err != nil {
+			return nil, err
+		}
+		processes = append(processes, procfile...)
+	}
+	for _, process := range cfg.Processes {
+		processes = append(processes, gowatch.ProcessConfig{
+			Name:    process.Name,
+			Command: process.Command,
+		})
+	}
+	return processes, nil
+}
+
+//appConfigs convert the apps of the config file into the apps of the watcher
+func appConfigs(apps []config.App) []gowatch.AppConfig {
+	configs := make([]gowatch.AppConfig, 0, len(apps))
+	for _, app := range apps {
+		configs = append(configs, gowatch.AppConfig{
+			Name:       app.Name,
+			Package:    app.Package,
+			BuildFlags: app.Buildflags,
+			RunFlags:   app.RunFlags,
+			Ignore:     app.Ignore,
+			Output:     app.Output,
+			BuildCmd:   app.BuildCmd,
+			RunCmd:     app.RunCmd,
+			Hooks: gowatch.Hooks{
+				BeforeBuild: app.BeforeBuild,
+				AfterBuild:  app.AfterBuild,
+				BeforeStart: app.BeforeStart,
+				AfterStop:   app.AfterStop,
+			},
+		})
+	}
+	return configs
+}
+
+var signals = map[string]os.Signal{
+	"HUP":  syscall.SIGHUP,
+	"INT":  syscall.SIGINT,
+	"QUIT": syscall.SIGQUIT,
+	"KILL": syscall.SIGKILL,
+	"TERM": syscall.SIGTERM,
+}
+
+//parseSignal convert a signal name like SIGTERM or TERM into a os.Signal,
+//an empty name return a nil signal to use the gowatch default
+func parseSignal(name string) (os.Signal, error) {
+	if name == "" {
+		return nil, nil
+	}
+	sig, ok := signals[strings.TrimPrefix(strings.ToUpper(name), "SIG")]
+	if !ok {
+		return nil, fmt.Errorf("unsupported stop signal %q", name)
+	}
+	return sig, nil
 }
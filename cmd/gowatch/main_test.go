@@ -2,9 +2,14 @@ package main
 
 import (
 	"errors"
+	"io/ioutil"
 	"os"
+	"syscall"
 	"testing"
+	"time"
 
+	"github.com/msalcantara/gowatch/cmd/gowatch/config"
+	"github.com/sirupsen/logrus"
 	"gopkg.in/yaml.v2"
 )
 
@@ -91,7 +96,7 @@ func TestInitConfigDirPwd(t *testing.T) {
 func TestInitConfigCmdFlags(t *testing.T) {
 	errTemplate := "%s don't load correctlly from command line: %v"
 	dir := "/tmp/whatever/dir"
-	cfg, err := cli([]string{"-d", dir, "-V", "-i", "*_test.go", "--build-flags", "x,v", "localhost 8000"})
+	cfg, err := cli([]string{"-d", dir, "-V", "-i", "*_test.go", "-e", ".go,.sql", "--include", "go.mod", "--build-flags", "x,v", "localhost 8000"})
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -104,6 +109,12 @@ func TestInitConfigCmdFlags(t *testing.T) {
 	if len(cfg.Ignore) == 0 {
 		t.Errorf(errTemplate, "ignore", cfg.Ignore)
 	}
+	if len(cfg.Extensions) != 2 {
+		t.Errorf(errTemplate, "ext", cfg.Extensions)
+	}
+	if len(cfg.Include) != 1 {
+		t.Errorf(errTemplate, "include", cfg.Include)
+	}
 	if len(cfg.Buildflags) == 0 {
 		t.Errorf(errTemplate, "build-flags", cfg.Buildflags)
 	}
@@ -139,3 +150,226 @@ func TestInitConfigConfigFile(t *testing.T) {
 		t.Errorf(errTemplate, "run-flags", cfg.RunFlags)
 	}
 }
+
+func TestInitConfigAppFlags(t *testing.T) {
+	errTemplate := "%s don't load correctlly from command line: %v"
+	cfg, err := cli([]string{"--stop-signal", "SIGINT", "--kill-timeout", "2s", "--debounce", "1s", "-o", "bin/app"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.StopSignal != "SIGINT" {
+		t.Errorf(errTemplate, "stop-signal", cfg.StopSignal)
+	}
+	if cfg.KillTimeout != 2*time.Second {
+		t.Errorf(errTemplate, "kill-timeout", cfg.KillTimeout)
+	}
+	if cfg.Debounce == nil || *cfg.Debounce != time.Second {
+		t.Errorf(errTemplate, "debounce", cfg.Debounce)
+	}
+	if cfg.Output != "bin/app" {
+		t.Errorf(errTemplate, "output", cfg.Output)
+	}
+}
+
+func TestInitConfigDebounceZero(t *testing.T) {
+	cfg, err := cli([]string{"--debounce=0"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.Debounce == nil || *cfg.Debounce != 0 {
+		t.Errorf(assertErrorMsg, 0, cfg.Debounce)
+	}
+	cfg, err = cli(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.Debounce != nil {
+		t.Errorf(assertErrorMsg, nil, *cfg.Debounce)
+	}
+}
+
+func TestParseSignal(t *testing.T) {
+	for name, expected := range map[string]os.Signal{
+		"":        nil,
+		"SIGTERM": syscall.SIGTERM,
+		"term":    syscall.SIGTERM,
+		"SIGINT":  syscall.SIGINT,
+		"KILL":    syscall.SIGKILL,
+	} {
+		sig, err := parseSignal(name)
+		if err != nil {
+			t.Fatalf(unexpectedErrorMsg, err)
+		}
+		if sig != expected {
+			t.Errorf(assertErrorMsg, expected, sig)
+		}
+	}
+}
+
+func TestParseSignalInvalid(t *testing.T) {
+	if _, err := parseSignal("SIGFOO"); err == nil {
+		t.Error("expected error to parse invalid signal")
+	}
+}
+
+func TestInitConfigGitignore(t *testing.T) {
+	cfg, err := cli([]string{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.Gitignore != nil {
+		t.Errorf(assertErrorMsg, nil, *cfg.Gitignore)
+	}
+
+	cfg, err = cli([]string{"--no-gitignore"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.Gitignore == nil || *cfg.Gitignore {
+		t.Errorf(assertErrorMsg, false, cfg.Gitignore)
+	}
+}
+
+func TestInitConfigExcludeDirs(t *testing.T) {
+	cfg, err := cli([]string{"--exclude-dirs", ".git,dist"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(cfg.ExcludeDirs) != 2 {
+		t.Errorf(assertErrorMsg, []string{".git", "dist"}, cfg.ExcludeDirs)
+	}
+}
+
+func TestWatcherOptionsInvalidSignal(t *testing.T) {
+	cfg, err := cli([]string{"--stop-signal", "SIGFOO"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := watcherOptions(cfg); err == nil {
+		t.Error("expected error of invalid stop signal")
+	}
+}
+
+func TestInitConfigPoll(t *testing.T) {
+	cfg, err := cli([]string{"--poll", "--poll-interval", "1s"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !cfg.Poll {
+		t.Errorf(assertErrorMsg, true, cfg.Poll)
+	}
+	if cfg.PollInterval != time.Second {
+		t.Errorf(assertErrorMsg, time.Second, cfg.PollInterval)
+	}
+}
+
+func TestInitConfigCommands(t *testing.T) {
+	cfg, err := cli([]string{"--build-cmd", "make build", "--run-cmd", "go run ."})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.BuildCmd != "make build" {
+		t.Errorf(assertErrorMsg, "make build", cfg.BuildCmd)
+	}
+	if cfg.RunCmd != "go run ." {
+		t.Errorf(assertErrorMsg, "go run .", cfg.RunCmd)
+	}
+}
+
+func TestInitConfigPackage(t *testing.T) {
+	cfg, err := cli([]string{"-p", "./cmd/server"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.Package != "./cmd/server" {
+		t.Errorf(assertErrorMsg, "./cmd/server", cfg.Package)
+	}
+}
+
+func TestAppConfigs(t *testing.T) {
+	apps := appConfigs([]config.App{
+		{Name: "api", Package: "./cmd/api", Buildflags: []string{"-v"}, RunFlags: []string{"serve"}},
+		{Name: "worker", Ignore: []string{"*.tmpl"}, BuildCmd: "make worker", RunCmd: "{{.Output}}", AfterStop: []string{"echo stopped"}},
+	})
+	if len(apps) != 2 {
+		t.Fatalf(assertErrorMsg, 2, len(apps))
+	}
+	if apps[0].Name != "api" || apps[0].Package != "./cmd/api" || apps[0].BuildFlags[0] != "-v" || apps[0].RunFlags[0] != "serve" {
+		t.Errorf(assertErrorMsg, "api app", apps[0])
+	}
+	if apps[1].Name != "worker" || apps[1].Ignore[0] != "*.tmpl" || apps[1].BuildCmd != "make worker" || apps[1].RunCmd != "{{.Output}}" ||
+		len(apps[1].Hooks.AfterStop) != 1 {
+		t.Errorf(assertErrorMsg, "worker app", apps[1])
+	}
+}
+
+func TestProcessConfigs(t *testing.T) {
+	procfile, err := ioutil.TempFile("", "Procfile")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.Remove(procfile.Name())
+	if _, err := procfile.WriteString("assets: npm run watch\n"); err != nil {
+		t.Fatal(err)
+	}
+	procfile.Close()
+
+	cfg, err := cli([]string{"--procfile", procfile.Name()})
+	if err != nil {
+		t.Fatal(err)
+	}
+	cfg.Processes = []config.Process{{Name: "stub", Command: "go run ./stub"}}
+	processes, err := processConfigs(cfg)
+	if err != nil {
+		t.Fatalf(unexpectedErrorMsg, err)
+	}
+	if len(processes) != 2 || processes[0].Name != "assets" || processes[1].Name != "stub" {
+		t.Errorf(assertErrorMsg, "[assets stub]", processes)
+	}
+
+	cfg.Procfile = "notfound"
+	if _, err := watcherOptions(cfg); err == nil {
+		t.Error("expected error of Procfile not found")
+	}
+}
+
+func TestInitConfigRestart(t *testing.T) {
+	cfg, err := cli([]string{"--restart", "always", "--max-retries", "3"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.Restart != "always" {
+		t.Errorf(assertErrorMsg, "always", cfg.Restart)
+	}
+	if cfg.MaxRetries != 3 {
+		t.Errorf(assertErrorMsg, 3, cfg.MaxRetries)
+	}
+
+	// unlimited retries override the config file
+	cfg, err = cli([]string{"-c", "./testdata/gowatch.yml", "--max-retries=0"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.MaxRetries != 0 {
+		t.Errorf(assertErrorMsg, 0, cfg.MaxRetries)
+	}
+
+	cfg.Restart = "sometimes"
+	if _, err := watcherOptions(cfg); err == nil {
+		t.Error("expected error of unsupported restart policy")
+	}
+}
+
+func TestNewLogger(t *testing.T) {
+	cfg, err := cli([]string{"-V"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	logger := newLogger(cfg)
+	if logger.GetLevel() != logrus.DebugLevel {
+		t.Errorf(assertErrorMsg, logrus.DebugLevel, logger.GetLevel())
+	}
+	if logrus.GetLevel() != logrus.InfoLevel {
+		t.Errorf("standard logger should not be changed: %v", logrus.GetLevel())
+	}
+}
@@ -0,0 +1,91 @@
+package gowatch
+
+import (
+	"time"
+)
+
+//EventType kind of the lifecycle events of the apps
+type EventType string
+
+const (
+	//FileChanged files that restart an app changed
+	FileChanged EventType = "file-changed"
+
+	//BuildStarted the build of an app started
+	BuildStarted EventType = "build-started"
+
+	//BuildSucceeded the build of an app finished successfully
+	BuildSucceeded EventType = "build-succeeded"
+
+	//BuildFailed the build of an app failed
+	BuildFailed EventType = "build-failed"
+
+	//BuildCanceled the build of an app was aborted by a newer
+	//change or because the watcher stopped
+	BuildCanceled EventType = "build-canceled"
+
+	//ProcessStarted the process of an app started
+	ProcessStarted EventType = "process-started"
+
+	//ProcessExited the process of an app exited, by itself or stopped by gowatch
+	ProcessExited EventType = "process-exited"
+)
+
+//Event a lifecycle event of an app run by the Watcher
+type Event struct {
+	//Type kind of the event
+	Type EventType
+
+	//Time when the event happened
+	Time time.Time
+
+	//App name of the app, empty when only one app is watched
+	App string
+
+	//Files changed files of FileChanged events
+	Files []string
+
+	//Duration time spent building of BuildSucceeded, BuildFailed and
+	//BuildCanceled events, and time running of ProcessExited events
+	Duration time.Duration
+
+	//Output error output of the build of BuildFailed events
+	Output string
+
+	//Err error of BuildFailed events
+	Err error
+
+	//Pid id of the process of ProcessStarted and ProcessExited events
+	Pid int
+
+	//ExitCode exit code of ProcessExited events, -1 if killed by a signal
+	ExitCode int
+}
+
+//Observer receive the lifecycle events of the apps. Notify is
+//called from many goroutines and should not block, since the
+//apps wait for it
+type Observer interface {
+	Notify(event Event)
+}
+
+//ObserverFunc use a function as an Observer
+type ObserverFunc func(event Event)
+
+//Notify call f with event
+func (f ObserverFunc) Notify(event Event) {
+	f(event)
+}
+
+//notifyObservers send event to each observer, at the current time
+func notifyObservers(observers []Observer, event Event) {
+	if len(observers) == 0 {
+		return
+	}
+	if event.Time.IsZero() {
+		event.Time = time.Now()
+	}
+	for _, observer := range observers {
+		observer.Notify(event)
+	}
+}
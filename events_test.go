@@ -0,0 +1,145 @@
+package gowatch
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+//eventRecorder an Observer that send the events to a channel
+type eventRecorder chan Event
+
+func (r eventRecorder) Notify(event Event) {
+	r <- event
+}
+
+func (r eventRecorder) next(t *testing.T) Event {
+	t.Helper()
+	select {
+	case event := <-r:
+		return event
+	case <-time.After(5 * time.Second):
+		t.Fatal("timeout waiting for event")
+	}
+	return Event{}
+}
+
+func TestNotifyObservers(t *testing.T) {
+	var events []Event
+	observer := ObserverFunc(func(event Event) {
+		events = append(events, event)
+	})
+	notifyObservers([]Observer{observer, observer}, Event{Type: FileChanged, Files: []string{"main.go"}})
+	if len(events) != 2 {
+		t.Fatalf(assertErrorMsg, 2, len(events))
+	}
+	if events[0].Type != FileChanged || events[0].Time.IsZero() {
+		t.Errorf(assertErrorMsg, "file changed event at the current time", events[0])
+	}
+}
+
+func TestBuildEvents(t *testing.T) {
+	pwd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	events := make(eventRecorder, 2)
+	target := &appTarget{name: "hello", app: AppRunner{
+		dir:        fmt.Sprintf("%s/testdata/helloworld", pwd),
+		binaryName: fmt.Sprintf("%s/testdata/helloworld/helloworld", pwd),
+	}}
+	w := Watcher{apps: []*appTarget{target}, observers: []Observer{events}}
+	if err := w.buildApp(context.Background(), target, target.app.Compile); err != nil {
+		t.Fatalf(unexpectedErrorMsg, err)
+	}
+	if event := events.next(t); event.Type != BuildStarted || event.App != "hello" {
+		t.Errorf(assertErrorMsg, BuildStarted, event)
+	}
+	if event := events.next(t); event.Type != BuildSucceeded || event.Duration <= 0 {
+		t.Errorf(assertErrorMsg, BuildSucceeded, event)
+	}
+}
+
+func TestBuildFailedEvent(t *testing.T) {
+	dir, err := ioutil.TempDir("", "TestBuildFailedEvent")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	for name, content := range map[string]string{
+		"go.mod":  "module broken\n",
+		"main.go": "package main\n\nfunc main() {",
+	} {
+		if err := ioutil.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	events := make(eventRecorder, 2)
+	target := &appTarget{app: AppRunner{dir: dir, binaryName: "broken"}}
+	w := Watcher{apps: []*appTarget{target}, observers: []Observer{events}}
+	if err := w.buildApp(context.Background(), target, target.app.Rebuild); !errors.Is(err, ErrCmdCompile) {
+		t.Fatalf(assertErrorMsg, ErrCmdCompile, err)
+	}
+	events.next(t)
+	event := events.next(t)
+	if event.Type != BuildFailed || event.Err == nil || event.Output == "" {
+		t.Errorf(assertErrorMsg, BuildFailed, event)
+	}
+}
+
+func TestBuildCanceledEvent(t *testing.T) {
+	events := make(eventRecorder, 2)
+	target := &appTarget{app: appTestSlowBuild{canceled: make(chan bool, 1)}}
+	w := Watcher{apps: []*appTarget{target}, observers: []Observer{events}}
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if err := w.buildApp(ctx, target, target.app.Rebuild); err != context.Canceled {
+		t.Fatalf(assertErrorMsg, context.Canceled, err)
+	}
+	if event := events.next(t); event.Type != BuildStarted {
+		t.Errorf(assertErrorMsg, BuildStarted, event)
+	}
+	if event := events.next(t); event.Type != BuildCanceled || event.Err != nil {
+		t.Errorf(assertErrorMsg, BuildCanceled, event)
+	}
+}
+
+func TestBuildEventsCustomApp(t *testing.T) {
+	events := make(eventRecorder, 3)
+	target := &appTarget{name: "custom", app: appTest{}, changes: map[string]bool{"main.go": true}}
+	w := Watcher{apps: []*appTarget{target}, observers: []Observer{events}}
+	w.restartApp(target)
+	if err := w.restartFinished(<-w.restartDone); err != nil {
+		t.Fatalf(unexpectedErrorMsg, err)
+	}
+	for _, expected := range []EventType{FileChanged, BuildStarted, BuildSucceeded} {
+		if event := events.next(t); event.Type != expected || event.App != "custom" {
+			t.Errorf(assertErrorMsg, expected, event)
+		}
+	}
+}
+
+func TestProcessEvents(t *testing.T) {
+	events := make(eventRecorder, 2)
+	target := &appTarget{name: "crash", app: commandRunner{AppRunner: AppRunner{dir: "."}, command: "exit 3"}}
+	w := Watcher{apps: []*appTarget{target}, observers: []Observer{events}}
+	p, err := target.app.Start()
+	if err != nil {
+		t.Fatalf(unexpectedErrorMsg, err)
+	}
+	target.process = p
+	w.supervise(target)
+
+	if event := events.next(t); event.Type != ProcessStarted || event.App != "crash" || event.Pid != p.Pid() {
+		t.Errorf(assertErrorMsg, ProcessStarted, event)
+	}
+	if event := events.next(t); event.Type != ProcessExited || event.ExitCode != 3 || event.Pid != p.Pid() {
+		t.Errorf(assertErrorMsg, ProcessExited, event)
+	}
+}
@@ -0,0 +1,140 @@
+package gowatch
+
+import (
+	"bufio"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+//gitignore hold the rules of the .gitignore files found in the watched directory
+type gitignore struct {
+	rules []gitignoreRule
+
+	//directories with .gitignore already loaded
+	loaded map[string]bool
+}
+
+type gitignoreRule struct {
+	//directory of the .gitignore file, relative to the watched directory
+	base string
+
+	//pattern split by slash
+	pattern []string
+
+	//rule start with ! and re-include the matched files
+	negate bool
+
+	//rule end with / and only match directories
+	dirOnly bool
+}
+
+//load read the .gitignore file of dir, if it exists, where
+//base is the path of dir relative to the watched directory
+func (g *gitignore) load(dir, base string) error {
+	base = filepath.ToSlash(base)
+	if base == "." {
+		base = ""
+	}
+	if g.loaded[base] {
+		return nil
+	}
+	file, err := os.Open(filepath.Join(dir, ".gitignore"))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return err
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		if rule, ok := parseGitignoreRule(base, scanner.Text()); ok {
+			g.rules = append(g.rules, rule)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return err
+	}
+	if g.loaded == nil {
+		g.loaded = make(map[string]bool)
+	}
+	g.loaded[base] = true
+	return nil
+}
+
+func parseGitignoreRule(base, line string) (gitignoreRule, bool) {
+	rule := gitignoreRule{base: base}
+	line = strings.TrimRight(line, " \t\r")
+	if line == "" || strings.HasPrefix(line, "#") {
+		return rule, false
+	}
+	if strings.HasPrefix(line, "!") {
+		rule.negate = true
+		line = line[1:]
+	}
+	line = strings.TrimPrefix(line, "\\")
+	if strings.HasSuffix(line, "/") {
+		rule.dirOnly = true
+		line = strings.TrimRight(line, "/")
+	}
+	if line == "" {
+		return rule, false
+	}
+	// patterns with a slash are relative to the .gitignore
+	// directory, the others match at any depth
+	anchored := strings.Contains(line, "/")
+	rule.pattern = strings.Split(strings.TrimPrefix(line, "/"), "/")
+	if !anchored {
+		rule.pattern = append([]string{"**"}, rule.pattern...)
+	}
+	for _, segment := range rule.pattern {
+		if _, err := path.Match(segment, ""); err != nil {
+			return rule, false
+		}
+	}
+	return rule, true
+}
+
+//ignored check if file, relative to the watched directory, or
+//any of its parent directories is ignored by the loaded rules
+func (g *gitignore) ignored(file string, isDir bool) bool {
+	if g == nil {
+		return false
+	}
+	file = filepath.ToSlash(file)
+	if file == "." || strings.HasPrefix(file, "../") {
+		return false
+	}
+	parts := strings.Split(file, "/")
+	for i := 1; i <= len(parts); i++ {
+		if g.match(parts[:i], isDir || i < len(parts)) {
+			return true
+		}
+	}
+	return false
+}
+
+//match apply the rules in order, the last matching rule win
+func (g *gitignore) match(file []string, isDir bool) bool {
+	ignored := false
+	for _, rule := range g.rules {
+		if rule.dirOnly && !isDir {
+			continue
+		}
+		name := file
+		if rule.base != "" {
+			base := strings.Split(rule.base, "/")
+			if len(name) <= len(base) || strings.Join(name[:len(base)], "/") != rule.base {
+				continue
+			}
+			name = name[len(base):]
+		}
+		if matched, _ := matchSegments(rule.pattern, name); matched {
+			ignored = !rule.negate
+		}
+	}
+	return ignored
+}
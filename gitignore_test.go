@@ -0,0 +1,76 @@
+package gowatch
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestGitignore(t *testing.T) {
+	dir, err := createTmpDir("TestGitignore")
+	if err != nil {
+		t.Fatalf(unexpectedErrorMsg, err)
+	}
+	defer os.RemoveAll(dir)
+	if err := os.MkdirAll(filepath.Join(dir, "web"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	files := map[string]string{
+		".gitignore":     "# build output\n/bin/\n*.log\n!keep.log\ntmp/\n\ndocs/*.md\n",
+		"web/.gitignore": "node_modules\n/dist\n",
+	}
+	for name, content := range files {
+		if err := ioutil.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	var g gitignore
+	if err := g.load(dir, "."); err != nil {
+		t.Fatalf(unexpectedErrorMsg, err)
+	}
+	if err := g.load(filepath.Join(dir, "web"), "web"); err != nil {
+		t.Fatalf(unexpectedErrorMsg, err)
+	}
+	// directory without .gitignore
+	if err := g.load(filepath.Join(dir, "foo"), "foo"); err != nil {
+		t.Fatalf(unexpectedErrorMsg, err)
+	}
+
+	for _, tc := range []struct {
+		file     string
+		isDir    bool
+		expected bool
+	}{
+		{"main.go", false, false},
+		{"bin", true, true},
+		{"bin/app", false, true},
+		{"foo/bin", true, false},
+		{"server.log", false, true},
+		{"foo/server.log", false, true},
+		{"keep.log", false, false},
+		{"tmp", true, true},
+		{"tmp", false, false},
+		{"foo/tmp/main.go", false, true},
+		{"docs/README.md", false, true},
+		{"docs/api/README.md", false, false},
+		{"web/node_modules", true, true},
+		{"web/node_modules/foo/index.js", false, true},
+		{"node_modules", true, false},
+		{"web/dist/app.js", false, true},
+		{"web/src/dist/app.js", false, false},
+		{"../main.go", false, false},
+	} {
+		if ignored := g.ignored(tc.file, tc.isDir); ignored != tc.expected {
+			t.Errorf("%s: "+assertErrorMsg, tc.file, tc.expected, ignored)
+		}
+	}
+}
+
+func TestGitignoreNil(t *testing.T) {
+	var g *gitignore
+	if g.ignored("main.go", false) {
+		t.Error("nil gitignore should not ignore files")
+	}
+}
@@ -0,0 +1,93 @@
+package gowatch
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+//listedPackage fields of the packages printed by go list -json
+type listedPackage struct {
+	Dir      string
+	Standard bool
+}
+
+//packageDeps return the directories of pkg and of the packages imported
+//by it, directly or not, found by go list run in dir with flags. The
+//packages of the standard library are not returned
+func packageDeps(ctx context.Context, dir, pkg string, flags []string) (map[string]bool, error) {
+	if pkg == "" {
+		pkg = "."
+	}
+	var stdout, stderr bytes.Buffer
+	args := append([]string{"list", "-deps", "-json"}, flags...)
+	cmd := exec.CommandContext(ctx, "go", append(args, pkg)...)
+	cmd.Dir = dir
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return nil, fmt.Errorf("error to list dependencies of %s: %v: %s", pkg, err, bytes.TrimSpace(stderr.Bytes()))
+	}
+
+	deps := make(map[string]bool)
+	decoder := json.NewDecoder(&stdout)
+	for {
+		var p listedPackage
+		if err := decoder.Decode(&p); err != nil {
+			if err == io.EOF {
+				break
+			}
+			return nil, fmt.Errorf("error to read dependencies of %s: %v", pkg, err)
+		}
+		if p.Standard || p.Dir == "" {
+			continue
+		}
+		deps[filepath.Clean(p.Dir)] = true
+	}
+	return deps, nil
+}
+
+//tagsFlags return the flags of buildFlags that choose the build tags,
+//so go list find the same packages imported by the build of the app
+func tagsFlags(buildFlags []string) []string {
+	var flags []string
+	for i := 0; i < len(buildFlags); i++ {
+		flag := buildFlags[i]
+		name := strings.TrimPrefix(strings.SplitN(flag, "=", 2)[0], "-")
+		if name != "-tags" && name != "tags" {
+			continue
+		}
+		flags = append(flags, flag)
+		// the tags are the next argument of -tags without =
+		if !strings.Contains(flag, "=") && i+1 < len(buildFlags) {
+			i++
+			flags = append(flags, buildFlags[i])
+		}
+	}
+	return flags
+}
+
+//isModuleFile check if file change the dependencies of every package
+func isModuleFile(file string) bool {
+	base := filepath.Base(file)
+	return base == "go.mod" || base == "go.sum"
+}
+
+//listDeps return the dependencies of the app of t built in dir,
+//or nil if they are unknown
+func listDeps(ctx context.Context, dir string, t *appTarget, logger Logger) map[string]bool {
+	if !t.listDeps {
+		return nil
+	}
+	deps, err := packageDeps(ctx, dir, t.depsPkg, t.depsFlags)
+	if err != nil {
+		logger.Debugf("%v, any change restart %s\n", err, t)
+		return nil
+	}
+	return deps
+}
@@ -0,0 +1,211 @@
+package gowatch
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestPackageDeps(t *testing.T) {
+	deps, err := packageDeps(context.Background(), "./testdata/multiapp", "./cmd/server", nil)
+	if err != nil {
+		t.Fatalf(unexpectedErrorMsg, err)
+	}
+	for _, dir := range []string{"cmd/server", "internal/greet"} {
+		abs, err := filepath.Abs(filepath.Join("testdata/multiapp", dir))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !deps[abs] {
+			t.Errorf("%s should be a dependency: %v", dir, deps)
+		}
+	}
+	if len(deps) != 2 {
+		t.Errorf(assertErrorMsg, 2, len(deps))
+	}
+}
+
+func TestPackageDepsTags(t *testing.T) {
+	deps, err := packageDeps(context.Background(), "./testdata/multiapp", "./cmd/server", []string{"-tags", "debug"})
+	if err != nil {
+		t.Fatalf(unexpectedErrorMsg, err)
+	}
+	abs, err := filepath.Abs("testdata/multiapp/internal/debug")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !deps[abs] {
+		t.Errorf("package imported with the debug tag should be a dependency: %v", deps)
+	}
+}
+
+func TestPackageDepsError(t *testing.T) {
+	if _, err := packageDeps(context.Background(), "./testdata/multiapp", "./cmd/notfound", nil); err == nil {
+		t.Error("expected error to list a package that does not exist")
+	}
+}
+
+func TestListDeps(t *testing.T) {
+	target := &appTarget{depsPkg: "./cmd/worker"}
+	if deps := listDeps(context.Background(), "./testdata/multiapp", target, defaultLogger); deps != nil {
+		t.Errorf("dependencies should not be listed: %v", deps)
+	}
+	target.listDeps = true
+	if deps := listDeps(context.Background(), "./testdata/multiapp", target, defaultLogger); len(deps) != 1 {
+		t.Errorf(assertErrorMsg, 1, len(deps))
+	}
+	target.depsPkg = "./cmd/notfound"
+	if deps := listDeps(context.Background(), "./testdata/multiapp", target, defaultLogger); deps != nil {
+		t.Errorf("dependencies of a package not found should be unknown: %v", deps)
+	}
+}
+
+func TestAffectsDeps(t *testing.T) {
+	dir, err := filepath.Abs("./testdata/multiapp")
+	if err != nil {
+		t.Fatal(err)
+	}
+	server := &appTarget{
+		name: "server",
+		deps: map[string]bool{
+			filepath.Join(dir, "cmd", "server"):     true,
+			filepath.Join(dir, "internal", "greet"): true,
+		},
+	}
+	w := Watcher{dir: dir, apps: []*appTarget{server}}
+
+	tests := []struct {
+		file     string
+		expected bool
+	}{
+		{"internal/greet/greet.go", true},
+		{"internal/greet/greet_test.go", false},
+		{"cmd/server/main.go", true},
+		{"cmd/worker/main.go", false},
+		{"go.mod", true},
+		{"templates/index.tmpl", true},
+	}
+	for _, test := range tests {
+		affected, err := w.affects(server, filepath.Join(dir, test.file))
+		if err != nil {
+			t.Fatalf(unexpectedErrorMsg, err)
+		}
+		if affected != test.expected {
+			t.Errorf("%s: "+assertErrorMsg, test.file, test.expected, affected)
+		}
+	}
+}
+
+func TestTagsFlags(t *testing.T) {
+	tests := []struct {
+		buildFlags []string
+		expected   []string
+	}{
+		{nil, nil},
+		{[]string{"-x", "-v"}, nil},
+		{[]string{"-race", "-tags=debug,dev"}, []string{"-tags=debug,dev"}},
+		{[]string{"-tags", "debug", "-v"}, []string{"-tags", "debug"}},
+		{[]string{"--tags=debug"}, []string{"--tags=debug"}},
+		{[]string{"-tagsx"}, nil},
+	}
+	for _, test := range tests {
+		flags := tagsFlags(test.buildFlags)
+		if strings.Join(flags, " ") != strings.Join(test.expected, " ") {
+			t.Errorf(assertErrorMsg, test.expected, flags)
+		}
+	}
+}
+
+func TestRestartImportPackageLater(t *testing.T) {
+	dir, err := createTmpDir("TestRestartImportPackageLater")
+	if err != nil {
+		t.Fatalf(unexpectedErrorMsg, err)
+	}
+	defer os.RemoveAll(dir)
+	// the missing package is not looked up in the module proxy
+	defer os.Setenv("GOPROXY", os.Getenv("GOPROXY"))
+	os.Setenv("GOPROXY", "off")
+	files := map[string]string{
+		"go.mod":  "module example.com/app\n\ngo 1.13\n",
+		"main.go": "package main\n\nimport \"time\"\n\nfunc main() { time.Sleep(time.Minute) }\n",
+	}
+	for name, content := range files {
+		if err := ioutil.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	target := &appTarget{
+		app:      AppRunner{dir: dir, binaryName: filepath.Join(dir, "app")},
+		depsPkg:  ".",
+		listDeps: true,
+	}
+	w := Watcher{dir: dir, apps: []*appTarget{target}}
+	if err := w.startApps(context.Background()); err != nil {
+		t.Fatalf(unexpectedErrorMsg, err)
+	}
+	defer w.stopApps()
+	restart := func(file string) {
+		target.changes = map[string]bool{file: true}
+		w.restartApp(target)
+		if err := w.restartFinished(<-w.restartDone); err != nil {
+			t.Fatalf(unexpectedErrorMsg, err)
+		}
+	}
+
+	// import a package that does not exist yet, the build fail
+	mainFile := filepath.Join(dir, "main.go")
+	main := `package main
+
+import (
+	"time"
+
+	"example.com/app/greet"
+)
+
+func main() {
+	greet.Hello()
+	time.Sleep(time.Minute)
+}
+`
+	if err := ioutil.WriteFile(mainFile, []byte(main), 0644); err != nil {
+		t.Fatal(err)
+	}
+	restart(mainFile)
+
+	// then create the package
+	greetDir := filepath.Join(dir, "greet")
+	if err := os.Mkdir(greetDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	greetFile := filepath.Join(greetDir, "greet.go")
+	if err := ioutil.WriteFile(greetFile, []byte("package greet\n\nfunc Hello() {}\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	affected, err := w.affects(target, greetFile)
+	if err != nil {
+		t.Fatalf(unexpectedErrorMsg, err)
+	}
+	if !affected {
+		t.Fatal("package created after the failed build should restart the app")
+	}
+	restart(greetFile)
+	if abs, _ := filepath.Abs(greetDir); !target.deps[abs] {
+		t.Errorf("%s should be a dependency: %v", greetDir, target.deps)
+	}
+}
+
+func TestIsModuleFile(t *testing.T) {
+	for file, expected := range map[string]bool{
+		"/src/go.mod":      true,
+		"go.sum":           true,
+		"/src/main.go":     false,
+		"/src/go.mod.tmpl": false,
+	} {
+		if isModuleFile(file) != expected {
+			t.Errorf("%s: "+assertErrorMsg, file, expected, !expected)
+		}
+	}
+}
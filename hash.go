@@ -0,0 +1,112 @@
+package gowatch
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+)
+
+//fileHash return the hash of the content of file,
+//or an empty string if file does not exist
+func fileHash(file string) (string, error) {
+	f, err := os.Open(file)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return "", nil
+		}
+		return "", err
+	}
+	defer f.Close()
+	hash := sha256.New()
+	if _, err := io.Copy(hash, f); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(hash.Sum(nil)), nil
+}
+
+//hashDirectories save the hash of the watched files of directories
+//as the content of the running apps they affect
+func (w *Watcher) hashDirectories(directories ...string) error {
+	for _, t := range w.apps {
+		if t.hashes == nil {
+			t.hashes = make(map[string]string)
+		}
+	}
+	for _, dir := range directories {
+		infos, err := ioutil.ReadDir(dir)
+		if err != nil {
+			return err
+		}
+		for _, info := range infos {
+			file := filepath.Join(dir, info.Name())
+			if info.IsDir() || !w.isRelevantFile(file) {
+				continue
+			}
+			hash, err := fileHash(file)
+			if err != nil {
+				return err
+			}
+			for _, t := range w.apps {
+				if affected, err := w.affects(t, file); err == nil && affected {
+					t.hashes[file] = hash
+				}
+			}
+		}
+	}
+	return nil
+}
+
+func (w *Watcher) isRelevantFile(file string) bool {
+	watch, err := w.isToWatchFile(file)
+	if err != nil || !watch {
+		return false
+	}
+	ignore, err := w.isToIgnoreFile(file)
+	return err == nil && !ignore
+}
+
+//contentChanged check if the content of files is different from
+//the content of the running app of t, saving the new hashes to be
+//committed after a successful restart
+func (t *appTarget) contentChanged(files []string, logger Logger) bool {
+	// without the hashes of the running app every change is relevant
+	if t.hashes == nil {
+		return true
+	}
+	if t.unbuiltHashes == nil {
+		t.unbuiltHashes = make(map[string]string)
+	}
+	for _, file := range files {
+		hash, err := fileHash(file)
+		if err != nil {
+			logger.Debugf("Error to hash %s: %v\n", file, err)
+			return true
+		}
+		old, ok := t.hashes[file]
+		if (hash == "" && !ok) || (ok && hash == old) {
+			delete(t.unbuiltHashes, file)
+			continue
+		}
+		t.unbuiltHashes[file] = hash
+	}
+	return len(t.unbuiltHashes) != 0
+}
+
+//commitHashes save the hashes of the changes built by
+//a successful restart as the content of the running app
+func (t *appTarget) commitHashes() {
+	if t.hashes == nil {
+		return
+	}
+	for file, hash := range t.unbuiltHashes {
+		if hash == "" {
+			delete(t.hashes, file)
+			continue
+		}
+		t.hashes[file] = hash
+	}
+	t.unbuiltHashes = nil
+}
@@ -0,0 +1,126 @@
+package gowatch
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/fsnotify/fsnotify"
+)
+
+func TestFileHash(t *testing.T) {
+	dir, err := createTmpDir("TestFileHash")
+	if err != nil {
+		t.Fatalf(unexpectedErrorMsg, err)
+	}
+	defer os.RemoveAll(dir)
+	file := filepath.Join(dir, "main.go")
+
+	hash, err := fileHash(file)
+	if err != nil {
+		t.Fatalf(unexpectedErrorMsg, err)
+	}
+	if hash != "" {
+		t.Errorf(assertErrorMsg, "", hash)
+	}
+
+	if err := ioutil.WriteFile(file, []byte("package main"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	hash, err = fileHash(file)
+	if err != nil {
+		t.Fatalf(unexpectedErrorMsg, err)
+	}
+	if err := ioutil.WriteFile(file, []byte("package main"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	sameHash, err := fileHash(file)
+	if err != nil {
+		t.Fatalf(unexpectedErrorMsg, err)
+	}
+	if hash == "" || hash != sameHash {
+		t.Errorf(assertErrorMsg, hash, sameHash)
+	}
+}
+
+func TestContentChanged(t *testing.T) {
+	dir, err := createTmpDir("TestContentChanged")
+	if err != nil {
+		t.Fatalf(unexpectedErrorMsg, err)
+	}
+	defer os.RemoveAll(dir)
+	file := filepath.Join(dir, "main.go")
+	if err := ioutil.WriteFile(file, []byte("package main"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	app := &appTarget{}
+	w := Watcher{dir: dir, apps: []*appTarget{app}}
+	if !app.contentChanged([]string{file}, defaultLogger) {
+		t.Error("without hashes every change should be relevant")
+	}
+
+	if err := w.hashDirectories(dir); err != nil {
+		t.Fatalf(unexpectedErrorMsg, err)
+	}
+	// rewrite the same content
+	if err := ioutil.WriteFile(file, []byte("package main"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if app.contentChanged([]string{file}, defaultLogger) {
+		t.Error("same content should not be a change")
+	}
+
+	if err := ioutil.WriteFile(file, []byte("package main\n\nfunc main() {"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if !app.contentChanged([]string{file}, defaultLogger) {
+		t.Error("new content should be a change")
+	}
+
+	// build failed and the change was reverted
+	if err := ioutil.WriteFile(file, []byte("package main"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if app.contentChanged([]string{file}, defaultLogger) {
+		t.Error("content of the running app should not be a change")
+	}
+
+	if err := os.Remove(file); err != nil {
+		t.Fatal(err)
+	}
+	if !app.contentChanged([]string{file}, defaultLogger) {
+		t.Error("removed file should be a change")
+	}
+	app.commitHashes()
+	if _, ok := app.hashes[file]; ok {
+		t.Error("removed file should not have hash")
+	}
+	if app.contentChanged([]string{file}, defaultLogger) {
+		t.Error("file already removed should not be a change")
+	}
+}
+
+func TestRestartContentUnchanged(t *testing.T) {
+	dir, err := createTmpDir("TestRestartContentUnchanged")
+	if err != nil {
+		t.Fatalf(unexpectedErrorMsg, err)
+	}
+	defer os.RemoveAll(dir)
+	file := filepath.Join(dir, "main.go")
+	if err := ioutil.WriteFile(file, []byte("package main"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	w := Watcher{dir: dir, apps: testApps(&appTestCounter{})}
+	if err := w.hashDirectories(dir); err != nil {
+		t.Fatalf(unexpectedErrorMsg, err)
+	}
+	if err := w.restart(fsnotify.Event{Name: file, Op: fsnotify.Write}); err != nil {
+		t.Fatalf(unexpectedErrorMsg, err)
+	}
+	if w.restarting() {
+		t.Error("app should not restart when the content did not change")
+	}
+}
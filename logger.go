@@ -0,0 +1,61 @@
+package gowatch
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/sirupsen/logrus"
+)
+
+//Logger receive the logs of the watcher and the apps
+type Logger interface {
+	Debugf(format string, args ...interface{})
+	Infof(format string, args ...interface{})
+	Warnf(format string, args ...interface{})
+	Errorf(format string, args ...interface{})
+
+	//With return a Logger that add the attribute key with
+	//value to the messages, like the app, the pid or the files
+	With(key string, value interface{}) Logger
+}
+
+//defaultLogger log to the standard error with the info level,
+//without changing the standard logger of logrus
+var defaultLogger = NewLogrusLogger(logrus.New())
+
+//logrusLogger a Logger that write to a logrus logger
+type logrusLogger struct {
+	logger logrus.FieldLogger
+}
+
+//NewLogrusLogger create a Logger that write to logger,
+//like logrus.New() or an entry with fields
+func NewLogrusLogger(logger logrus.FieldLogger) Logger {
+	return logrusLogger{logger: logger}
+}
+
+func (l logrusLogger) Debugf(format string, args ...interface{}) {
+	l.logger.Debug(message(format, args))
+}
+
+func (l logrusLogger) Infof(format string, args ...interface{}) {
+	l.logger.Info(message(format, args))
+}
+
+func (l logrusLogger) Warnf(format string, args ...interface{}) {
+	l.logger.Warn(message(format, args))
+}
+
+func (l logrusLogger) Errorf(format string, args ...interface{}) {
+	l.logger.Error(message(format, args))
+}
+
+func (l logrusLogger) With(key string, value interface{}) Logger {
+	return logrusLogger{logger: l.logger.WithField(key, value)}
+}
+
+//message format the log message, without the line break at the
+//end, since the loggers write each message in its own line
+func message(format string, args []interface{}) string {
+	return strings.TrimSuffix(fmt.Sprintf(format, args...), "\n")
+}
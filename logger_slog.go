@@ -0,0 +1,38 @@
+//go:build go1.21
+// +build go1.21
+
+package gowatch
+
+import (
+	"log/slog"
+)
+
+//slogLogger a Logger that write to a slog logger
+type slogLogger struct {
+	logger *slog.Logger
+}
+
+//NewSlogLogger create a Logger that write to logger
+func NewSlogLogger(logger *slog.Logger) Logger {
+	return slogLogger{logger: logger}
+}
+
+func (l slogLogger) Debugf(format string, args ...interface{}) {
+	l.logger.Debug(message(format, args))
+}
+
+func (l slogLogger) Infof(format string, args ...interface{}) {
+	l.logger.Info(message(format, args))
+}
+
+func (l slogLogger) Warnf(format string, args ...interface{}) {
+	l.logger.Warn(message(format, args))
+}
+
+func (l slogLogger) Errorf(format string, args ...interface{}) {
+	l.logger.Error(message(format, args))
+}
+
+func (l slogLogger) With(key string, value interface{}) Logger {
+	return slogLogger{logger: l.logger.With(key, value)}
+}
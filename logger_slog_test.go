@@ -0,0 +1,32 @@
+//go:build go1.21
+// +build go1.21
+
+package gowatch
+
+import (
+	"bytes"
+	"log/slog"
+	"testing"
+)
+
+func TestSlogLogger(t *testing.T) {
+	var buf bytes.Buffer
+	handler := slog.NewTextHandler(&buf, &slog.HandlerOptions{
+		Level: slog.LevelInfo,
+		ReplaceAttr: func(groups []string, a slog.Attr) slog.Attr {
+			if a.Key == slog.TimeKey {
+				return slog.Attr{}
+			}
+			return a
+		},
+	})
+	logger := NewSlogLogger(slog.New(handler))
+
+	logger.Debugf("hidden %d\n", 1)
+	logger.Warnf("Restarting %s\n", "app")
+	logger.With("app", "api").With("pid", 42).Errorf("app %s", "crashed")
+	expected := "level=WARN msg=\"Restarting app\"\nlevel=ERROR msg=\"app crashed\" app=api pid=42\n"
+	if buf.String() != expected {
+		t.Errorf(assertErrorMsg, expected, buf.String())
+	}
+}
@@ -0,0 +1,67 @@
+package gowatch
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/sirupsen/logrus"
+)
+
+func TestLogrusLogger(t *testing.T) {
+	var buf bytes.Buffer
+	l := logrus.New()
+	l.SetOutput(&buf)
+	l.SetFormatter(&logrus.TextFormatter{DisableTimestamp: true})
+	logger := NewLogrusLogger(l)
+
+	logger.Debugf("hidden %d\n", 1)
+	logger.Infof("Restarting %s\n", "app")
+	logger.Errorf("app %s", "crashed")
+	expected := "level=info msg=\"Restarting app\"\nlevel=error msg=\"app crashed\"\n"
+	if buf.String() != expected {
+		t.Errorf(assertErrorMsg, expected, buf.String())
+	}
+}
+
+func TestLogrusLoggerWith(t *testing.T) {
+	var buf bytes.Buffer
+	l := logrus.New()
+	l.SetOutput(&buf)
+	l.SetFormatter(&logrus.TextFormatter{DisableTimestamp: true})
+	logger := NewLogrusLogger(l).With("app", "api")
+
+	logger.With("pid", 42).Errorf("api exited with code %d\n", 1)
+	expected := "level=error msg=\"api exited with code 1\" app=api pid=42\n"
+	if buf.String() != expected {
+		t.Errorf(assertErrorMsg, expected, buf.String())
+	}
+}
+
+func TestAppLog(t *testing.T) {
+	var buf bytes.Buffer
+	l := logrus.New()
+	l.SetOutput(&buf)
+	l.SetFormatter(&logrus.TextFormatter{DisableTimestamp: true})
+	w := Watcher{logger: NewLogrusLogger(l)}
+
+	w.appLog(&appTarget{}).Infof("Restarting %s\n", "app")
+	w.appLog(&appTarget{name: "api"}).Infof("Restarting %s\n", "api")
+	expected := "level=info msg=\"Restarting app\"\nlevel=info msg=\"Restarting api\" app=api\n"
+	if buf.String() != expected {
+		t.Errorf(assertErrorMsg, expected, buf.String())
+	}
+}
+
+func TestDefaultLogger(t *testing.T) {
+	var buf bytes.Buffer
+	output := logrus.StandardLogger().Out
+	logrus.SetOutput(&buf)
+	defer logrus.SetOutput(output)
+
+	w := Watcher{}
+	w.log().Errorf("Error to stop %s\n", "app")
+	if strings.Contains(buf.String(), "Error to stop") {
+		t.Error("default logger should not write to the standard logger of logrus")
+	}
+}
@@ -0,0 +1,58 @@
+package gowatch
+
+import (
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+//matchPattern report whether file match pattern. Patterns with
+//a slash are matched against the whole path of file, where "**"
+//match any number of directories, like vendor/** or internal/**/mocks/*.go.
+//Patterns without a slash, like *_test.go, match the name of file
+//in any directory
+func matchPattern(pattern, file string) (bool, error) {
+	pattern = strings.TrimSuffix(filepath.ToSlash(pattern), "/")
+	file = filepath.ToSlash(file)
+	if !strings.Contains(pattern, "/") {
+		return path.Match(pattern, path.Base(file))
+	}
+	pattern = strings.TrimPrefix(pattern, "./")
+	return matchSegments(strings.Split(pattern, "/"), strings.Split(file, "/"))
+}
+
+func matchSegments(pattern, file []string) (bool, error) {
+	for len(pattern) != 0 {
+		if pattern[0] == "**" {
+			for i := 0; i <= len(file); i++ {
+				matched, err := matchSegments(pattern[1:], file[i:])
+				if matched || err != nil {
+					return matched, err
+				}
+			}
+			return false, nil
+		}
+		if len(file) == 0 {
+			return false, nil
+		}
+		matched, err := path.Match(pattern[0], file[0])
+		if !matched || err != nil {
+			return false, err
+		}
+		pattern, file = pattern[1:], file[1:]
+	}
+	return len(file) == 0, nil
+}
+
+func matchAny(patterns []string, file string) (bool, error) {
+	for _, pattern := range patterns {
+		matched, err := matchPattern(pattern, file)
+		if err != nil {
+			return false, err
+		}
+		if matched {
+			return matched, nil
+		}
+	}
+	return false, nil
+}
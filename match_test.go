@@ -0,0 +1,40 @@
+package gowatch
+
+import (
+	"testing"
+)
+
+func TestMatchPattern(t *testing.T) {
+	for _, tc := range []struct {
+		pattern, file string
+		expected      bool
+	}{
+		{"*_test.go", "main_test.go", true},
+		{"*_test.go", "foo/bar/main_test.go", true},
+		{"*_test.go", "main.go", false},
+		{"vendor/**", "vendor", true},
+		{"vendor/**", "vendor/github.com/foo/foo.go", true},
+		{"vendor/**", "foo/vendor/foo.go", false},
+		{"./vendor/", "vendor", true},
+		{"internal/**/mocks/*.go", "internal/mocks/foo.go", true},
+		{"internal/**/mocks/*.go", "internal/billing/api/mocks/foo.go", true},
+		{"internal/**/mocks/*.go", "internal/billing/api/mocks/foo/foo.go", false},
+		{"**/*.sql", "migrations/001.sql", true},
+		{"migrations/*.sql", "migrations/foo/001.sql", false},
+		{"/tmp/*/main.go", "/tmp/foo/main.go", true},
+	} {
+		matched, err := matchPattern(tc.pattern, tc.file)
+		if err != nil {
+			t.Fatalf(unexpectedErrorMsg, err)
+		}
+		if matched != tc.expected {
+			t.Errorf("%s %s: "+assertErrorMsg, tc.pattern, tc.file, tc.expected, matched)
+		}
+	}
+}
+
+func TestMatchPatternError(t *testing.T) {
+	if _, err := matchPattern("foo/[", "foo/bar"); err == nil {
+		t.Error("expected error of invalid pattern")
+	}
+}
@@ -0,0 +1,308 @@
+package gowatch
+
+import (
+	"os"
+	"strings"
+	"time"
+)
+
+//Option configure optional settings of a Watcher
+type Option func(*options)
+
+type options struct {
+	//flags to use in go build command
+	buildFlags []string
+
+	//flags to use in the app execution
+	runFlags []string
+
+	//pattern of files to not watch
+	ignore []string
+
+	//app to run instead of the one built from the watched directory
+	app App
+
+	//receive the logs of the watcher and the apps
+	logger Logger
+
+	//source of the file changes events
+	source EventSource
+
+	//receive the lifecycle events of the apps
+	observers []Observer
+
+	//signal sent to ask the app to exit
+	stopSignal os.Signal
+
+	//time to wait for the app to exit before killing it
+	killTimeout time.Duration
+
+	//time to wait for more changes before restarting the app
+	debounce time.Duration
+
+	//path of the compiled binary
+	output string
+
+	//extensions of files to watch
+	extensions []string
+
+	//pattern of files to watch besides the extensions
+	include []string
+
+	//ignore the files listed in .gitignore files
+	gitignore bool
+
+	//name patterns of directories to not watch
+	excludeDirs []string
+
+	//poll for changes instead of using file system notifications
+	poll bool
+
+	//time between checks for changes when polling
+	pollInterval time.Duration
+
+	//commands to run during the lifecycle of the app
+	hooks Hooks
+
+	//command template to compile the app instead of go build
+	buildCmd string
+
+	//main package to compile instead of the watched directory
+	pkg string
+
+	//command template to run the app instead of the compiled binary
+	runCmd string
+
+	//apps to run instead of the single app of the watched directory
+	apps []AppConfig
+
+	//auxiliary processes run alongside the apps
+	processes []ProcessConfig
+
+	//when to restart apps that exited by themselves
+	restartPolicy RestartPolicy
+
+	//times to restart a crashed app before waiting for changes
+	maxRetries int
+}
+
+func defaultOptions() options {
+	return options{
+		stopSignal:  DefaultStopSignal,
+		killTimeout: DefaultKillTimeout,
+		debounce:    DefaultDebounce,
+		gitignore:   true,
+		excludeDirs: DefaultExcludeDirs,
+		logger:      defaultLogger,
+
+		restartPolicy: RestartNever,
+	}
+}
+
+//WithBuildFlags set the flags used in the go build command of the app
+func WithBuildFlags(flags ...string) Option {
+	return func(o *options) {
+		o.buildFlags = append(o.buildFlags, flags...)
+	}
+}
+
+//WithRunFlags set the flags used in the execution of the app
+func WithRunFlags(flags ...string) Option {
+	return func(o *options) {
+		o.runFlags = append(o.runFlags, flags...)
+	}
+}
+
+//WithIgnore set patterns of files that are not watched
+func WithIgnore(patterns ...string) Option {
+	return func(o *options) {
+		o.ignore = append(o.ignore, patterns...)
+	}
+}
+
+//WithApp run app instead of the app built from the watched directory,
+//any change of the watched files restart it
+func WithApp(app App) Option {
+	return func(o *options) {
+		o.app = app
+	}
+}
+
+//WithLogger set where the watcher and the apps write their logs, like
+//NewLogrusLogger or NewSlogLogger. By default they are written to the
+//standard error, the standard logger of logrus is never changed
+func WithLogger(logger Logger) Option {
+	return func(o *options) {
+		if logger != nil {
+			o.logger = logger
+		}
+	}
+}
+
+//WithEventSource set the source of the file changes events,
+//replacing the file system notifications and polling
+func WithEventSource(source EventSource) Option {
+	return func(o *options) {
+		o.source = source
+	}
+}
+
+//WithObserver send the lifecycle events of the apps to observers,
+//like the changes of files, the builds and the start of processes
+func WithObserver(observers ...Observer) Option {
+	return func(o *options) {
+		o.observers = append(o.observers, observers...)
+	}
+}
+
+//WithStopSignal set the signal sent to the app when it need to stop
+func WithStopSignal(sig os.Signal) Option {
+	return func(o *options) {
+		if sig != nil {
+			o.stopSignal = sig
+		}
+	}
+}
+
+//WithKillTimeout set how long to wait for the app to exit
+//after the stop signal before killing it
+func WithKillTimeout(timeout time.Duration) Option {
+	return func(o *options) {
+		if timeout > 0 {
+			o.killTimeout = timeout
+		}
+	}
+}
+
+//WithDebounce set how long to wait for more changes before
+//restarting the app, all changes in this window cause a single
+//restart. With 0 each change restart the app right away
+func WithDebounce(debounce time.Duration) Option {
+	return func(o *options) {
+		o.debounce = debounce
+	}
+}
+
+//WithOutput set where the compiled binary is written, relative
+//paths are relative to the watched directory. By default the binary
+//is written in the user cache directory
+func WithOutput(output string) Option {
+	return func(o *options) {
+		if output != "" {
+			o.output = output
+		}
+	}
+}
+
+//WithExtensions set the extensions of files that restart the app
+//when changed, by default only .go files are watched
+func WithExtensions(extensions ...string) Option {
+	return func(o *options) {
+		for _, ext := range extensions {
+			if ext == "" {
+				continue
+			}
+			if !strings.HasPrefix(ext, ".") {
+				ext = "." + ext
+			}
+			o.extensions = append(o.extensions, ext)
+		}
+	}
+}
+
+//WithInclude set patterns of files that restart the app
+//when changed besides the ones with watched extensions
+func WithInclude(patterns ...string) Option {
+	return func(o *options) {
+		o.include = append(o.include, patterns...)
+	}
+}
+
+//WithGitignore set if the files listed in .gitignore files of the
+//watched directory are ignored, it is enabled by default
+func WithGitignore(enabled bool) Option {
+	return func(o *options) {
+		o.gitignore = enabled
+	}
+}
+
+//WithExcludeDirs set name patterns of directories that are not watched,
+//replacing DefaultExcludeDirs
+func WithExcludeDirs(dirs ...string) Option {
+	return func(o *options) {
+		o.excludeDirs = dirs
+	}
+}
+
+//WithPolling check for changes every interval instead of using
+//the file system notifications, that don't work in some network
+//and container mounted directories
+func WithPolling(interval time.Duration) Option {
+	return func(o *options) {
+		o.poll = true
+		o.pollInterval = interval
+	}
+}
+
+//WithHooks set commands to run during the lifecycle of the app,
+//the hooks of many apps are set in the AppConfig of each app
+func WithHooks(hooks Hooks) Option {
+	return func(o *options) {
+		o.hooks = hooks
+	}
+}
+
+//WithBuildCmd set the command run by the shell to compile the app
+//instead of go build. The command is a text/template executed with
+//CommandData, like: go build -o {{.Output}} ./cmd/api
+func WithBuildCmd(command string) Option {
+	return func(o *options) {
+		o.buildCmd = command
+	}
+}
+
+//WithRunCmd set the command run by the shell to start the app instead
+//of the compiled binary. The command is a text/template executed with
+//CommandData, like: {{.Output}} --port 8080
+func WithRunCmd(command string) Option {
+	return func(o *options) {
+		o.runCmd = command
+	}
+}
+
+//WithPackage set the main package to compile, like ./cmd/server,
+//while the whole directory is still watched for changes
+func WithPackage(pkg string) Option {
+	return func(o *options) {
+		o.pkg = pkg
+	}
+}
+
+//WithApps run many apps of the watched directory at once, each one
+//restarted only by the changes that affect it. The build and run
+//settings of the other options are replaced by the ones of each app
+func WithApps(apps ...AppConfig) Option {
+	return func(o *options) {
+		o.apps = append(o.apps, apps...)
+	}
+}
+
+//WithProcesses run auxiliary processes alongside the apps, they are
+//started and stopped with gowatch but not restarted by changes
+func WithProcesses(processes ...ProcessConfig) Option {
+	return func(o *options) {
+		o.processes = append(o.processes, processes...)
+	}
+}
+
+//WithRestartPolicy set when to restart apps that exited by themselves,
+//waiting longer after each retry. After maxRetries the app is only
+//restarted by changes, 0 retry forever
+func WithRestartPolicy(policy RestartPolicy, maxRetries int) Option {
+	return func(o *options) {
+		if policy != "" {
+			o.restartPolicy = policy
+		}
+		o.maxRetries = maxRetries
+	}
+}
@@ -0,0 +1,46 @@
+package gowatch
+
+import (
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+func TestWithExtensions(t *testing.T) {
+	o := defaultOptions()
+	WithExtensions("go", ".tmpl", "")(&o)
+	expected := []string{".go", ".tmpl"}
+	if len(o.extensions) != len(expected) {
+		t.Fatalf(assertErrorMsg, expected, o.extensions)
+	}
+	for i, ext := range o.extensions {
+		if ext != expected[i] {
+			t.Errorf(assertErrorMsg, expected[i], ext)
+		}
+	}
+}
+
+func TestWithDebounce(t *testing.T) {
+	o := defaultOptions()
+	WithDebounce(0)(&o)
+	if o.debounce != 0 {
+		t.Errorf(assertErrorMsg, 0, o.debounce)
+	}
+	if _, err := New("./testdata/helloworld/", WithDebounce(-time.Second)); err == nil {
+		t.Error("expected error of negative debounce")
+	}
+}
+
+func TestWithLogger(t *testing.T) {
+	o := defaultOptions()
+	WithLogger(nil)(&o)
+	if o.logger != defaultLogger {
+		t.Errorf(assertErrorMsg, defaultLogger, o.logger)
+	}
+	logger := NewLogrusLogger(logrus.New())
+	WithLogger(logger)(&o)
+	if o.logger != logger {
+		t.Errorf(assertErrorMsg, logger, o.logger)
+	}
+}
@@ -0,0 +1,58 @@
+package gowatch
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+)
+
+//colors ANSI codes of the colors of the prefixes
+var colors = []int{36, 33, 32, 35, 34, 31}
+
+//prefixWriter write the prefix at the start of each line written to w,
+//so the output of many apps running at once can be told apart
+type prefixWriter struct {
+	mu sync.Mutex
+
+	w      io.Writer
+	prefix []byte
+
+	//the next byte written start a new line
+	midLine bool
+}
+
+func newPrefixWriter(w io.Writer, prefix string) *prefixWriter {
+	return &prefixWriter{w: w, prefix: []byte(prefix)}
+}
+
+func (p *prefixWriter) Write(data []byte) (int, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	var buf bytes.Buffer
+	for _, line := range bytes.SplitAfter(data, []byte("\n")) {
+		if len(line) == 0 {
+			continue
+		}
+		if !p.midLine {
+			buf.Write(p.prefix)
+		}
+		buf.Write(line)
+		p.midLine = line[len(line)-1] != '\n'
+	}
+	if _, err := p.w.Write(buf.Bytes()); err != nil {
+		return 0, err
+	}
+	return len(data), nil
+}
+
+//colorize paint text with the color of index i, unless
+//the NO_COLOR environment variable is set
+func colorize(text string, i int) string {
+	if _, ok := os.LookupEnv("NO_COLOR"); ok {
+		return text
+	}
+	return fmt.Sprintf("\x1b[%dm%s\x1b[0m", colors[i%len(colors)], text)
+}
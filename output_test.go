@@ -0,0 +1,40 @@
+package gowatch
+
+import (
+	"bytes"
+	"os"
+	"testing"
+)
+
+func TestPrefixWriter(t *testing.T) {
+	var buf bytes.Buffer
+	w := newPrefixWriter(&buf, "[api] ")
+	for _, data := range []string{"hello\nwor", "ld\n", "\n", "bye"} {
+		n, err := w.Write([]byte(data))
+		if err != nil {
+			t.Fatalf(unexpectedErrorMsg, err)
+		}
+		if n != len(data) {
+			t.Errorf(assertErrorMsg, len(data), n)
+		}
+	}
+	expected := "[api] hello\n[api] world\n[api] \n[api] bye"
+	if buf.String() != expected {
+		t.Errorf(assertErrorMsg, expected, buf.String())
+	}
+}
+
+func TestColorize(t *testing.T) {
+	os.Unsetenv("NO_COLOR")
+	if text := colorize("api", 0); text != "\x1b[36mapi\x1b[0m" {
+		t.Errorf(assertErrorMsg, "\x1b[36mapi\x1b[0m", text)
+	}
+	if colorize("api", 0) == colorize("api", 1) {
+		t.Error("apps should have different colors")
+	}
+	os.Setenv("NO_COLOR", "1")
+	defer os.Unsetenv("NO_COLOR")
+	if text := colorize("api", 0); text != "api" {
+		t.Errorf(assertErrorMsg, "api", text)
+	}
+}
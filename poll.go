@@ -0,0 +1,171 @@
+package gowatch
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+)
+
+//DefaultPollInterval time between checks of the polling watcher
+const DefaultPollInterval = 500 * time.Millisecond
+
+//pollingSource EventSource that check the modification time and
+//size of the watched files periodically, for file systems where
+//the operating system notifications don't work, like network and
+//docker mounted directories
+type pollingSource struct {
+	interval time.Duration
+
+	mu sync.Mutex
+
+	//files of each watched directory
+	dirs map[string]map[string]fileState
+
+	events chan fsnotify.Event
+	errors chan error
+	done   chan struct{}
+	once   sync.Once
+}
+
+type fileState struct {
+	modTime time.Time
+	size    int64
+	isDir   bool
+}
+
+func newPollingSource(interval time.Duration) *pollingSource {
+	if interval <= 0 {
+		interval = DefaultPollInterval
+	}
+	s := &pollingSource{
+		interval: interval,
+		dirs:     make(map[string]map[string]fileState),
+		events:   make(chan fsnotify.Event),
+		errors:   make(chan error),
+		done:     make(chan struct{}),
+	}
+	go s.run()
+	return s
+}
+
+func (s *pollingSource) Add(dir string) error {
+	files, err := readDirState(dir)
+	if err != nil {
+		return err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.dirs[filepath.Clean(dir)] = files
+	return nil
+}
+
+func (s *pollingSource) Remove(dir string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.dirs, filepath.Clean(dir))
+	return nil
+}
+
+func (s *pollingSource) Events() <-chan fsnotify.Event {
+	return s.events
+}
+
+func (s *pollingSource) Errors() <-chan error {
+	return s.errors
+}
+
+func (s *pollingSource) Close() error {
+	s.once.Do(func() {
+		close(s.done)
+	})
+	return nil
+}
+
+func (s *pollingSource) run() {
+	ticker := time.NewTicker(s.interval)
+	defer ticker.Stop()
+	defer close(s.errors)
+	defer close(s.events)
+	for {
+		select {
+		case <-s.done:
+			return
+		case <-ticker.C:
+		}
+		events, errs := s.poll()
+		for _, event := range events {
+			select {
+			case s.events <- event:
+			case <-s.done:
+				return
+			}
+		}
+		for _, err := range errs {
+			select {
+			case s.errors <- err:
+			case <-s.done:
+				return
+			}
+		}
+	}
+}
+
+//poll compare the current files of the watched
+//directories with the last known state
+func (s *pollingSource) poll() ([]fsnotify.Event, []error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var (
+		events []fsnotify.Event
+		errs   []error
+	)
+	for dir, old := range s.dirs {
+		files, err := readDirState(dir)
+		if err != nil {
+			if os.IsNotExist(err) {
+				delete(s.dirs, dir)
+				events = append(events, fsnotify.Event{Name: dir, Op: fsnotify.Remove})
+				continue
+			}
+			errs = append(errs, err)
+			continue
+		}
+		for name, state := range files {
+			oldState, ok := old[name]
+			switch {
+			case !ok:
+				events = append(events, fsnotify.Event{Name: name, Op: fsnotify.Create})
+			case !state.isDir && (!state.modTime.Equal(oldState.modTime) || state.size != oldState.size):
+				events = append(events, fsnotify.Event{Name: name, Op: fsnotify.Write})
+			}
+		}
+		for name := range old {
+			if _, ok := files[name]; !ok {
+				events = append(events, fsnotify.Event{Name: name, Op: fsnotify.Remove})
+			}
+		}
+		s.dirs[dir] = files
+	}
+	return events, errs
+}
+
+func readDirState(dir string) (map[string]fileState, error) {
+	infos, err := ioutil.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	files := make(map[string]fileState, len(infos))
+	for _, info := range infos {
+		files[filepath.Join(dir, info.Name())] = fileState{
+			modTime: info.ModTime(),
+			size:    info.Size(),
+			isDir:   info.IsDir(),
+		}
+	}
+	return files, nil
+}
@@ -0,0 +1,100 @@
+package gowatch
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+)
+
+func nextPollEvent(t *testing.T, s *pollingSource) fsnotify.Event {
+	select {
+	case event := <-s.Events():
+		return event
+	case err := <-s.Errors():
+		t.Fatalf(unexpectedErrorMsg, err)
+	case <-time.After(5 * time.Second):
+		t.Fatal("timeout waiting for polling event")
+	}
+	return fsnotify.Event{}
+}
+
+func TestPollingSource(t *testing.T) {
+	dir, err := createTmpDir("TestPollingSource")
+	if err != nil {
+		t.Fatalf(unexpectedErrorMsg, err)
+	}
+	defer os.RemoveAll(dir)
+
+	s := newPollingSource(10 * time.Millisecond)
+	defer s.Close()
+	if err := s.Add(dir); err != nil {
+		t.Fatalf(unexpectedErrorMsg, err)
+	}
+
+	file := filepath.Join(dir, "main.go")
+	if err := ioutil.WriteFile(file, nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+	if event := nextPollEvent(t, s); event.Name != file || event.Op != fsnotify.Create {
+		t.Errorf(assertErrorMsg, fsnotify.Event{Name: file, Op: fsnotify.Create}, event)
+	}
+
+	if err := ioutil.WriteFile(file, []byte("package main"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if event := nextPollEvent(t, s); event.Name != file || event.Op != fsnotify.Write {
+		t.Errorf(assertErrorMsg, fsnotify.Event{Name: file, Op: fsnotify.Write}, event)
+	}
+
+	if err := os.Remove(file); err != nil {
+		t.Fatal(err)
+	}
+	if event := nextPollEvent(t, s); event.Name != file || event.Op != fsnotify.Remove {
+		t.Errorf(assertErrorMsg, fsnotify.Event{Name: file, Op: fsnotify.Remove}, event)
+	}
+}
+
+func TestPollingSourceRemoveDir(t *testing.T) {
+	dir, err := createTmpDir("TestPollingSourceRemoveDir")
+	if err != nil {
+		t.Fatalf(unexpectedErrorMsg, err)
+	}
+	defer os.RemoveAll(dir)
+
+	s := newPollingSource(10 * time.Millisecond)
+	defer s.Close()
+	if err := s.Add(dir); err != nil {
+		t.Fatalf(unexpectedErrorMsg, err)
+	}
+	if err := os.Remove(dir); err != nil {
+		t.Fatal(err)
+	}
+	if event := nextPollEvent(t, s); event.Name != dir || event.Op != fsnotify.Remove {
+		t.Errorf(assertErrorMsg, fsnotify.Event{Name: dir, Op: fsnotify.Remove}, event)
+	}
+
+	// removed directories are not watched anymore
+	if err := s.Remove(dir); err != nil {
+		t.Fatalf(unexpectedErrorMsg, err)
+	}
+	if err := s.Add(dir); err == nil {
+		t.Error("expected error to watch removed directory")
+	}
+}
+
+func TestPollingSourceClose(t *testing.T) {
+	s := newPollingSource(10 * time.Millisecond)
+	if err := s.Close(); err != nil {
+		t.Fatalf(unexpectedErrorMsg, err)
+	}
+	if err := s.Close(); err != nil {
+		t.Fatalf(unexpectedErrorMsg, err)
+	}
+	if _, ok := <-s.Events(); ok {
+		t.Error("events channel should be closed")
+	}
+}
@@ -0,0 +1,144 @@
+package gowatch
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"syscall"
+)
+
+//Process a running process of an app, started by the App
+type Process interface {
+	//Wait wait for the process to exit, returning an error if it
+	//failed. It can be called many times, from many goroutines
+	Wait() error
+
+	//Signal send sig to the process
+	Signal(sig os.Signal) error
+
+	//Pid id of the process
+	Pid() int
+
+	//ExitCode exit code of the exited process, -1 if it
+	//is still running or was killed by a signal
+	ExitCode() int
+}
+
+//runningProcess a Process of a command waited in background,
+//so its exit can be observed by many goroutines
+type runningProcess struct {
+	cmd *exec.Cmd
+
+	//closed when the process exit
+	done chan struct{}
+
+	//state of the exited process
+	state *os.ProcessState
+}
+
+//startProcess start cmd and wait for it in background
+func startProcess(cmd *exec.Cmd) (*runningProcess, error) {
+	if err := cmd.Start(); err != nil {
+		return nil, err
+	}
+	p := &runningProcess{
+		cmd:  cmd,
+		done: make(chan struct{}),
+	}
+	go func() {
+		// the exit is reported by the state of the process
+		_ = cmd.Wait()
+		p.state = cmd.ProcessState
+		close(p.done)
+	}()
+	return p, nil
+}
+
+//runProcessGroup run cmd in its own process group and wait for it,
+//killing the whole group if ctx is done before cmd exits. Killing
+//only a shell would leave its children holding the output of cmd
+func runProcessGroup(ctx context.Context, cmd *exec.Cmd) error {
+	setProcessGroup(cmd)
+	if err := cmd.Start(); err != nil {
+		return err
+	}
+	done := make(chan error, 1)
+	go func() {
+		done <- cmd.Wait()
+	}()
+	select {
+	case err := <-done:
+		return err
+	case <-ctx.Done():
+		_ = signalProcessGroup(cmd, os.Kill)
+		<-done
+		return ctx.Err()
+	}
+}
+
+func (p *runningProcess) Wait() error {
+	<-p.done
+	if !p.state.Success() {
+		return &exec.ExitError{ProcessState: p.state}
+	}
+	return nil
+}
+
+//Signal send sig to every process in the group of the process
+func (p *runningProcess) Signal(sig os.Signal) error {
+	return signalProcessGroup(p.cmd, sig)
+}
+
+func (p *runningProcess) Pid() int {
+	return p.cmd.Process.Pid
+}
+
+func (p *runningProcess) ExitCode() int {
+	if !p.exited() {
+		return -1
+	}
+	return p.state.ExitCode()
+}
+
+func (p *runningProcess) exited() bool {
+	select {
+	case <-p.done:
+		return true
+	default:
+		return false
+	}
+}
+
+//String describe how the process exited
+func (p *runningProcess) String() string {
+	if !p.exited() {
+		return fmt.Sprintf("running with pid %d", p.Pid())
+	}
+	if status, ok := p.state.Sys().(syscall.WaitStatus); ok && status.Signaled() {
+		return fmt.Sprintf("killed by signal %v", status.Signal())
+	}
+	return fmt.Sprintf("exited with code %d", p.state.ExitCode())
+}
+
+//processDone return a channel closed when p exit
+func processDone(p Process) <-chan struct{} {
+	if running, ok := p.(*runningProcess); ok {
+		return running.done
+	}
+	done := make(chan struct{})
+	go func() {
+		// the exit is reported by the exit code of the process
+		_ = p.Wait()
+		close(done)
+	}()
+	return done
+}
+
+//describeExit describe how p exited
+func describeExit(p Process) string {
+	if s, ok := p.(fmt.Stringer); ok {
+		return s.String()
+	}
+	return fmt.Sprintf("exited with code %d", p.ExitCode())
+}
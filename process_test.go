@@ -0,0 +1,100 @@
+package gowatch
+
+import (
+	"os"
+	"testing"
+	"time"
+)
+
+//processTest a Process that exit when it receive any signal
+type processTest struct {
+	signals chan os.Signal
+	done    chan struct{}
+}
+
+func newProcessTest() *processTest {
+	p := &processTest{signals: make(chan os.Signal, 2), done: make(chan struct{})}
+	go func() {
+		<-p.signals
+		close(p.done)
+	}()
+	return p
+}
+
+func (p *processTest) Wait() error {
+	<-p.done
+	return nil
+}
+
+func (p *processTest) Signal(sig os.Signal) error {
+	p.signals <- sig
+	return nil
+}
+
+func (p *processTest) Pid() int      { return 42 }
+func (p *processTest) ExitCode() int { return 0 }
+
+func TestRunningProcessExit(t *testing.T) {
+	p, err := startProcess(newCmd(".", "sh", "-c", "exit 3"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := p.Wait(); err == nil {
+		t.Error("expected error of process exited with code 3")
+	}
+	if p.ExitCode() != 3 {
+		t.Errorf(assertErrorMsg, 3, p.ExitCode())
+	}
+	if status := describeExit(p); status != "exited with code 3" {
+		t.Errorf(assertErrorMsg, "exited with code 3", status)
+	}
+	if RestartNever.restart(p) || !RestartOnFailure.restart(p) || !RestartAlways.restart(p) {
+		t.Error("only on-failure and always policies should restart a failed process")
+	}
+}
+
+func TestRunningProcessSignal(t *testing.T) {
+	p, err := startProcess(newCmd(".", "sleep", "30"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p.ExitCode() != -1 {
+		t.Errorf(assertErrorMsg, -1, p.ExitCode())
+	}
+	if err := p.Signal(os.Kill); err != nil {
+		t.Fatal(err)
+	}
+	<-processDone(p)
+	if status := describeExit(p); status != "killed by signal killed" {
+		t.Errorf(assertErrorMsg, "killed by signal killed", status)
+	}
+	if p.ExitCode() != -1 {
+		t.Errorf(assertErrorMsg, -1, p.ExitCode())
+	}
+}
+
+func TestStopRunningProcess(t *testing.T) {
+	p, err := startProcess(newCmd(".", "sleep", "30"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := (AppRunner{}).Stop(p); err != nil {
+		t.Fatalf(unexpectedErrorMsg, err)
+	}
+	if !p.exited() {
+		t.Error("process should be stopped")
+	}
+}
+
+func TestStopProcess(t *testing.T) {
+	p := newProcessTest()
+	if err := (AppRunner{killTimeout: time.Second}).Stop(p); err != nil {
+		t.Fatalf(unexpectedErrorMsg, err)
+	}
+	if sig := <-p.signals; sig != os.Kill {
+		t.Errorf(assertErrorMsg, os.Kill, sig)
+	}
+	if status := describeExit(p); status != "exited with code 0" {
+		t.Errorf(assertErrorMsg, "exited with code 0", status)
+	}
+}
@@ -0,0 +1,50 @@
+//go:build !windows
+// +build !windows
+
+package gowatch
+
+import (
+	"os"
+	"os/exec"
+	"syscall"
+)
+
+//setProcessGroup make cmd start in a new process group, so the
+//app and any process started by it can be signaled together. The
+//new group is in background and is stopped by SIGTTIN if it read
+//the terminal, so cmd read from the null device instead of it
+func setProcessGroup(cmd *exec.Cmd) {
+	if cmd.SysProcAttr == nil {
+		cmd.SysProcAttr = &syscall.SysProcAttr{}
+	}
+	cmd.SysProcAttr.Setpgid = true
+	if f, ok := cmd.Stdin.(*os.File); ok && isTerminal(f) {
+		cmd.Stdin = nil
+	}
+}
+
+//isTerminal check if f is a terminal, or another character device
+func isTerminal(f *os.File) bool {
+	info, err := f.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
+}
+
+//signalProcessGroup send sig to every process in the group of cmd,
+//if cmd was not started in its own group only cmd is signaled
+func signalProcessGroup(cmd *exec.Cmd, sig os.Signal) error {
+	s, ok := sig.(syscall.Signal)
+	if !ok || cmd.SysProcAttr == nil || !cmd.SysProcAttr.Setpgid {
+		return cmd.Process.Signal(sig)
+	}
+	// a negative pid signal the whole process group
+	return syscall.Kill(-cmd.Process.Pid, s)
+}
+
+//shellCommand return the command to run command by the shell,
+//with args appended as arguments of command
+func shellCommand(command string, args ...string) (string, []string) {
+	if len(args) == 0 {
+		return "sh", []string{"-c", command}
+	}
+	return "sh", append([]string{"-c", command + ` "$@"`, "sh"}, args...)
+}
@@ -0,0 +1,202 @@
+//go:build !windows
+// +build !windows
+
+package gowatch
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"syscall"
+	"testing"
+	"time"
+)
+
+//startProcessTree start a shell in its own process group that
+//spawn two sleep processes and return their pids
+func startProcessTree(t *testing.T, app AppRunner, script string) []int {
+	dir, err := createTmpDir("TestProcessGroup")
+	if err != nil {
+		t.Fatalf(unexpectedErrorMsg, err)
+	}
+	defer os.RemoveAll(dir)
+	pidFile := filepath.Join(dir, "pids")
+
+	p, err := startProcess(cmdRunBinary(dir, "/bin/sh", "-c", fmt.Sprintf(
+		"%s sleep 30 & echo $! >> %s; sleep 30 & echo $! >> %s; wait",
+		script, pidFile, pidFile,
+	)))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var pids []int
+	for start := time.Now(); len(pids) < 2; time.Sleep(10 * time.Millisecond) {
+		if time.Since(start) > 5*time.Second {
+			t.Fatal("timeout waiting for child processes to start")
+		}
+		data, err := ioutil.ReadFile(pidFile)
+		if err != nil || !bytes.HasSuffix(data, []byte("\n")) {
+			continue
+		}
+		pids = pids[:0]
+		for _, line := range strings.Fields(string(data)) {
+			pid, err := strconv.Atoi(line)
+			if err != nil {
+				t.Fatal(err)
+			}
+			pids = append(pids, pid)
+		}
+	}
+
+	if err := app.Stop(p); err != nil {
+		t.Fatalf(unexpectedErrorMsg, err)
+	}
+	return pids
+}
+
+//processRunning check if pid is alive, zombie processes
+//waiting to be reaped by init are considered dead
+func processRunning(pid int) bool {
+	if err := syscall.Kill(pid, 0); err != nil {
+		return false
+	}
+	stat, err := ioutil.ReadFile(fmt.Sprintf("/proc/%d/stat", pid))
+	if err != nil {
+		return !os.IsNotExist(err)
+	}
+	return !bytes.Contains(stat, []byte(") Z "))
+}
+
+func assertProcessesStopped(t *testing.T, pids []int) {
+	for _, pid := range pids {
+		for start := time.Now(); processRunning(pid); time.Sleep(10 * time.Millisecond) {
+			if time.Since(start) > time.Second {
+				t.Errorf("child process %d is still running", pid)
+				break
+			}
+		}
+	}
+}
+
+func TestStopProcessGroup(t *testing.T) {
+	app := AppRunner{killTimeout: 5 * time.Second}
+	start := time.Now()
+	pids := startProcessTree(t, app, "")
+	if elapsed := time.Since(start); elapsed >= app.killTimeout {
+		t.Errorf("process group should exit before kill timeout: %v", elapsed)
+	}
+	assertProcessesStopped(t, pids)
+}
+
+func TestStopProcessGroupKillTimeout(t *testing.T) {
+	app := AppRunner{killTimeout: 500 * time.Millisecond}
+	pids := startProcessTree(t, app, `trap "" TERM;`)
+	assertProcessesStopped(t, pids)
+}
+
+func TestSetProcessGroupStdin(t *testing.T) {
+	devNull, err := os.Open(os.DevNull)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer devNull.Close()
+	cmd := newCmd(".", "true")
+	cmd.Stdin = devNull
+	setProcessGroup(cmd)
+	if cmd.Stdin != nil {
+		t.Errorf("process group should not read from a character device: %v", cmd.Stdin)
+	}
+
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer r.Close()
+	defer w.Close()
+	cmd = newCmd(".", "true")
+	cmd.Stdin = r
+	setProcessGroup(cmd)
+	if cmd.Stdin != r {
+		t.Error("process group should read from a pipe")
+	}
+}
+
+func TestRunContext(t *testing.T) {
+	dir, err := createTmpDir("TestRunContext")
+	if err != nil {
+		t.Fatalf(unexpectedErrorMsg, err)
+	}
+	defer os.RemoveAll(dir)
+	pidFile := filepath.Join(dir, "pid")
+
+	w, err := NewWatcher("./testdata/helloworld/", nil, nil, nil,
+		WithOutput(filepath.Join(dir, "helloworld")),
+		WithRunCmd(fmt.Sprintf("echo $$ > %s; exec sleep 30", pidFile)))
+	if err != nil {
+		t.Fatalf(unexpectedErrorMsg, err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	errCh := make(chan error)
+	go func() {
+		errCh <- w.Run(ctx)
+	}()
+
+	var pid int
+	for i := 0; i < 100 && pid == 0; i++ {
+		time.Sleep(50 * time.Millisecond)
+		if data, err := ioutil.ReadFile(pidFile); err == nil {
+			fmt.Sscanf(string(data), "%d", &pid)
+		}
+	}
+	if pid == 0 {
+		t.Fatal("app should be started")
+	}
+	cancel()
+	if err := <-errCh; err != nil {
+		t.Fatalf(unexpectedErrorMsg, err)
+	}
+	if processRunning(pid) {
+		t.Errorf("app %d should be stopped", pid)
+	}
+	if err := w.Run(context.Background()); err != ErrAlreadyRun {
+		t.Errorf(assertErrorMsg, ErrAlreadyRun, err)
+	}
+	if err := w.Close(); err != nil {
+		t.Errorf(unexpectedErrorMsg, err)
+	}
+}
+
+func TestRebuildCancelShellCommands(t *testing.T) {
+	dir, err := createTmpDir("TestRebuildCancelShellCommands")
+	if err != nil {
+		t.Fatalf(unexpectedErrorMsg, err)
+	}
+	defer os.RemoveAll(dir)
+
+	apps := map[string]AppRunner{
+		"build command": {buildCmd: "sleep 3; go build -o {{.Output}} ."},
+		"before build":  {hooks: Hooks{BeforeBuild: []string{"sleep 3; true"}}},
+		"after build":   {hooks: Hooks{AfterBuild: []string{"sleep 3; true"}}},
+	}
+	for name, app := range apps {
+		app.dir = "./testdata/helloworld"
+		app.binaryName = filepath.Join(dir, "helloworld")
+		ctx, cancel := context.WithTimeout(context.Background(), 300*time.Millisecond)
+		start := time.Now()
+		err := app.Rebuild(ctx)
+		cancel()
+		if err != context.DeadlineExceeded {
+			t.Errorf("%s: "+assertErrorMsg, name, context.DeadlineExceeded, err)
+		}
+		// the children of the shell are killed with it
+		if elapsed := time.Since(start); elapsed > 2*time.Second {
+			t.Errorf("%s: build should be canceled, took %v", name, elapsed)
+		}
+	}
+}
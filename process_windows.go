@@ -0,0 +1,21 @@
+package gowatch
+
+import (
+	"os"
+	"os/exec"
+)
+
+//setProcessGroup is a no-op, process groups are not supported on windows
+func setProcessGroup(cmd *exec.Cmd) {}
+
+//signalProcessGroup kill the process of cmd, windows can't
+//send other signals to a process so any sig is a kill
+func signalProcessGroup(cmd *exec.Cmd, sig os.Signal) error {
+	return cmd.Process.Kill()
+}
+
+//shellCommand return the command to run command by the shell,
+//with args appended as arguments of command
+func shellCommand(command string, args ...string) (string, []string) {
+	return "cmd", append([]string{"/C", command}, args...)
+}
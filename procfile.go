@@ -0,0 +1,94 @@
+package gowatch
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+//ProcessConfig an auxiliary process run alongside the apps, like a
+//stub server or an assets watcher, that is not restarted by changes
+type ProcessConfig struct {
+	//Name identify the process in the logs and prefix its output
+	Name string
+
+	//Command run by the shell in the watched directory, as it
+	//is written, it is not a template like the commands of apps
+	Command string
+}
+
+//commandRunner run the command of an auxiliary process, that has
+//nothing to compile
+type commandRunner struct {
+	AppRunner
+
+	//shell command of the process, run as it is
+	command string
+}
+
+//Start run the command by the shell, without rendering it as a
+//template, so Procfile commands like docker ps --format '{{.Names}}'
+//keep their braces
+func (c commandRunner) Start() (Process, error) {
+	shell, args := shellCommand(c.command)
+	cmd := newCmd(c.dir, shell, args...)
+	setProcessGroup(cmd)
+	p, err := startProcess(c.prepareCmd(cmd))
+	if err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+func (c commandRunner) Compile(ctx context.Context) error {
+	return nil
+}
+
+//Rebuild has nothing to compile, the command run again as it is
+func (c commandRunner) Rebuild(ctx context.Context) error {
+	return nil
+}
+
+//Clean has nothing to remove, there is no compiled binary
+func (c commandRunner) Clean() error {
+	return nil
+}
+
+//ParseProcfile read the processes of a Procfile, where
+//each line has the name of a process and its command
+//separated by a colon, like: assets: npm run watch
+func ParseProcfile(r io.Reader) ([]ProcessConfig, error) {
+	var processes []ProcessConfig
+	scanner := bufio.NewScanner(r)
+	for n := 1; scanner.Scan(); n++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		parts := strings.SplitN(line, ":", 2)
+		if len(parts) != 2 || strings.TrimSpace(parts[0]) == "" || strings.TrimSpace(parts[1]) == "" {
+			return nil, fmt.Errorf("error to parse Procfile line %d: %q", n, line)
+		}
+		processes = append(processes, ProcessConfig{
+			Name:    strings.TrimSpace(parts[0]),
+			Command: strings.TrimSpace(parts[1]),
+		})
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("error to read Procfile: %v", err)
+	}
+	return processes, nil
+}
+
+//LoadProcfile read the processes of the Procfile file
+func LoadProcfile(file string) ([]ProcessConfig, error) {
+	f, err := os.Open(file)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return ParseProcfile(f)
+}
@@ -0,0 +1,104 @@
+package gowatch
+
+import (
+	"bytes"
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestParseProcfile(t *testing.T) {
+	procfile := `# stack of development
+stub: go run ./stub --port 9000
+
+assets:npm run watch:css
+`
+	processes, err := ParseProcfile(strings.NewReader(procfile))
+	if err != nil {
+		t.Fatalf(unexpectedErrorMsg, err)
+	}
+	expected := []ProcessConfig{
+		{Name: "stub", Command: "go run ./stub --port 9000"},
+		{Name: "assets", Command: "npm run watch:css"},
+	}
+	if len(processes) != len(expected) {
+		t.Fatalf(assertErrorMsg, expected, processes)
+	}
+	for i, process := range processes {
+		if process != expected[i] {
+			t.Errorf(assertErrorMsg, expected[i], process)
+		}
+	}
+}
+
+func TestParseProcfileInvalid(t *testing.T) {
+	for _, procfile := range []string{"stub", ": go run .", "stub:   "} {
+		if _, err := ParseProcfile(strings.NewReader(procfile)); err == nil {
+			t.Errorf("expected error to parse %q", procfile)
+		}
+	}
+}
+
+func TestLoadProcfile(t *testing.T) {
+	dir, err := createTmpDir("TestLoadProcfile")
+	if err != nil {
+		t.Fatalf(unexpectedErrorMsg, err)
+	}
+	defer os.RemoveAll(dir)
+	file := filepath.Join(dir, "Procfile")
+	if err := ioutil.WriteFile(file, []byte("stub: sleep 30\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	processes, err := LoadProcfile(file)
+	if err != nil {
+		t.Fatalf(unexpectedErrorMsg, err)
+	}
+	if len(processes) != 1 || processes[0].Name != "stub" {
+		t.Errorf(assertErrorMsg, "[stub]", processes)
+	}
+	if _, err := LoadProcfile(filepath.Join(dir, "notfound")); !os.IsNotExist(err) {
+		t.Errorf(assertErrorMsg, "not exist error", err)
+	}
+}
+
+func TestCommandRunner(t *testing.T) {
+	var stdout bytes.Buffer
+	runner := commandRunner{
+		AppRunner: AppRunner{
+			name:   "echo",
+			dir:    ".",
+			stdout: &stdout,
+		},
+		command: "echo $0 '{{.Names}}'",
+	}
+	if err := runner.Compile(context.Background()); err != nil {
+		t.Fatalf(unexpectedErrorMsg, err)
+	}
+	p, err := runner.Start()
+	if err != nil {
+		t.Fatalf(unexpectedErrorMsg, err)
+	}
+	if err := p.Wait(); err != nil {
+		t.Fatalf(unexpectedErrorMsg, err)
+	}
+	if err := runner.Rebuild(context.Background()); err != nil {
+		t.Fatalf(unexpectedErrorMsg, err)
+	}
+	p, err = runner.Start()
+	if err != nil {
+		t.Fatalf(unexpectedErrorMsg, err)
+	}
+	if err := p.Wait(); err != nil {
+		t.Fatalf(unexpectedErrorMsg, err)
+	}
+	// the command is not a template
+	if expected := "sh {{.Names}}\nsh {{.Names}}\n"; stdout.String() != expected {
+		t.Errorf(assertErrorMsg, expected, stdout.String())
+	}
+	if err := runner.Clean(); err != nil {
+		t.Errorf(unexpectedErrorMsg, err)
+	}
+}
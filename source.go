@@ -0,0 +1,57 @@
+package gowatch
+
+import (
+	"github.com/fsnotify/fsnotify"
+)
+
+//EventSource notify the changes of files inside the watched directories
+type EventSource interface {
+	//Add start watching the files of dir, not recursively
+	Add(dir string) error
+
+	//Remove stop watching the files of dir
+	Remove(dir string) error
+
+	//Events return the channel of changes in the watched files
+	Events() <-chan fsnotify.Event
+
+	//Errors return the channel of errors while watching files
+	Errors() <-chan error
+
+	//Close stop watching all files
+	Close() error
+}
+
+//fsnotifySource EventSource using the notifications
+//of the operating system, like inotify on linux
+type fsnotifySource struct {
+	watcher *fsnotify.Watcher
+}
+
+func newFsnotifySource() (*fsnotifySource, error) {
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return nil, err
+	}
+	return &fsnotifySource{watcher: watcher}, nil
+}
+
+func (s *fsnotifySource) Add(dir string) error {
+	return s.watcher.Add(dir)
+}
+
+func (s *fsnotifySource) Remove(dir string) error {
+	return s.watcher.Remove(dir)
+}
+
+func (s *fsnotifySource) Events() <-chan fsnotify.Event {
+	return s.watcher.Events
+}
+
+func (s *fsnotifySource) Errors() <-chan error {
+	return s.watcher.Errors
+}
+
+func (s *fsnotifySource) Close() error {
+	return s.watcher.Close()
+}
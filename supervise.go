@@ -0,0 +1,175 @@
+package gowatch
+
+import (
+	"fmt"
+	"time"
+)
+
+//RestartPolicy when to restart an app that exited by itself
+type RestartPolicy string
+
+const (
+	//RestartNever keep the app stopped until the next change
+	RestartNever RestartPolicy = "never"
+
+	//RestartOnFailure restart the app when it exit with an error or is killed by a signal
+	RestartOnFailure RestartPolicy = "on-failure"
+
+	//RestartAlways restart the app whenever it exit
+	RestartAlways RestartPolicy = "always"
+)
+
+const (
+	//DefaultRestartDelay time to wait before the first restart
+	//of a crashed app, doubled on each retry
+	DefaultRestartDelay = 500 * time.Millisecond
+
+	//maxRestartDelay the longest time to wait before restarting a crashed
+	//app, apps running for longer than it have their retries reset
+	maxRestartDelay = 30 * time.Second
+)
+
+//ParseRestartPolicy convert name into a RestartPolicy,
+//an empty name is RestartNever
+func ParseRestartPolicy(name string) (RestartPolicy, error) {
+	switch policy := RestartPolicy(name); policy {
+	case "":
+		return RestartNever, nil
+	case RestartNever, RestartOnFailure, RestartAlways:
+		return policy, nil
+	}
+	return "", fmt.Errorf("unsupported restart policy %q, use never, on-failure or always", name)
+}
+
+//processExit an app that exited without being asked by gowatch
+type processExit struct {
+	target  *appTarget
+	process Process
+	runtime time.Duration
+}
+
+//supervise wait for the running process of t to exit by itself
+func (w *Watcher) supervise(t *appTarget) {
+	p := t.process
+	if p == nil {
+		return
+	}
+	started := time.Now()
+	w.notify(t, Event{Type: ProcessStarted, Time: started, Pid: p.Pid()})
+	if w.exited == nil {
+		// each app has at most one supervised process
+		w.exited = make(chan processExit, len(w.apps))
+	}
+	go func(exited chan<- processExit, stop <-chan struct{}) {
+		<-processDone(p)
+		runtime := time.Since(started)
+		w.notify(t, Event{
+			Type:     ProcessExited,
+			Duration: runtime,
+			Pid:      p.Pid(),
+			ExitCode: p.ExitCode(),
+		})
+		if t.stoppedByWatcher(p) {
+			return
+		}
+		select {
+		case exited <- processExit{target: t, process: p, runtime: runtime}:
+		case <-stop:
+		}
+	}(w.exited, w.stop)
+}
+
+//processExited log the exit of an app and restart it,
+//if the restart policy allow it
+func (w *Watcher) processExited(exit processExit) error {
+	t := exit.target
+	logger := w.appLog(t).With("pid", exit.process.Pid())
+	if exit.process.ExitCode() != 0 {
+		logger.Errorf("%s %s after %v\n", t, describeExit(exit.process), exit.runtime.Round(time.Millisecond))
+	} else {
+		logger.Infof("%s %s after %v\n", t, describeExit(exit.process), exit.runtime.Round(time.Millisecond))
+	}
+	// a restart in progress start the app again
+	if t.cancelRestart != nil || t.process != exit.process {
+		return nil
+	}
+	if !w.restartPolicy.restart(exit.process) {
+		return nil
+	}
+	if exit.runtime >= maxRestartDelay {
+		t.retries = 0
+	}
+	w.scheduleRetry(t)
+	return nil
+}
+
+func (policy RestartPolicy) restart(p Process) bool {
+	switch policy {
+	case RestartAlways:
+		return true
+	case RestartOnFailure:
+		return p.ExitCode() != 0
+	}
+	return false
+}
+
+//scheduleRetry start the app of t again after the backoff
+//delay of its retries, unless the retries are over
+func (w *Watcher) scheduleRetry(t *appTarget) {
+	if w.maxRetries > 0 && t.retries >= w.maxRetries {
+		w.appLog(t).Errorf("%s restarted %d times, waiting for changes to restart it again\n", t, t.retries)
+		return
+	}
+	delay := retryDelay(t.retries)
+	t.retries++
+	w.appLog(t).Infof("Restarting %s in %v (retry %d)\n", t, delay, t.retries)
+
+	if w.retry == nil {
+		// a stopped timer may have fired already
+		w.retry = make(chan *appTarget, 2*len(w.apps))
+	}
+	t.stopRetry()
+	retry, stop := w.retry, w.stop
+	t.retryTimer = time.AfterFunc(delay, func() {
+		select {
+		case retry <- t:
+		case <-stop:
+		}
+	})
+}
+
+//retryDelay return the time to wait before restarting
+//a crashed app restarted retries times already
+func retryDelay(retries int) time.Duration {
+	delay := DefaultRestartDelay << uint(retries)
+	if delay <= 0 || delay > maxRestartDelay {
+		return maxRestartDelay
+	}
+	return delay
+}
+
+//retryApp start again the app of t that exited
+func (w *Watcher) retryApp(t *appTarget) error {
+	if t.retryTimer == nil || t.cancelRestart != nil {
+		// canceled by a change
+		return nil
+	}
+	t.retryTimer = nil
+	p, err := t.app.Start()
+	if err != nil {
+		w.appLog(t).Errorf("Error to start %s: %v\n", t, err)
+		w.scheduleRetry(t)
+		return nil
+	}
+	t.process = p
+	w.supervise(t)
+	return nil
+}
+
+//stopRetry cancel the scheduled restart of the crashed app of t
+func (t *appTarget) stopRetry() {
+	if t.retryTimer != nil {
+		t.retryTimer.Stop()
+		t.retryTimer = nil
+	}
+}
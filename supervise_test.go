@@ -0,0 +1,149 @@
+package gowatch
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseRestartPolicy(t *testing.T) {
+	for name, expected := range map[string]RestartPolicy{
+		"":           RestartNever,
+		"never":      RestartNever,
+		"on-failure": RestartOnFailure,
+		"always":     RestartAlways,
+	} {
+		policy, err := ParseRestartPolicy(name)
+		if err != nil {
+			t.Fatalf(unexpectedErrorMsg, err)
+		}
+		if policy != expected {
+			t.Errorf(assertErrorMsg, expected, policy)
+		}
+	}
+	if _, err := ParseRestartPolicy("sometimes"); err == nil {
+		t.Error("expected error of unsupported restart policy")
+	}
+}
+
+func TestRetryDelay(t *testing.T) {
+	for retries, expected := range map[int]time.Duration{
+		0:  DefaultRestartDelay,
+		1:  2 * DefaultRestartDelay,
+		3:  8 * DefaultRestartDelay,
+		20: maxRestartDelay,
+		80: maxRestartDelay,
+	} {
+		if delay := retryDelay(retries); delay != expected {
+			t.Errorf(assertErrorMsg, expected, delay)
+		}
+	}
+}
+
+func TestSuperviseRestartOnFailure(t *testing.T) {
+	dir, err := createTmpDir("TestSuperviseRestartOnFailure")
+	if err != nil {
+		t.Fatalf(unexpectedErrorMsg, err)
+	}
+	defer os.RemoveAll(dir)
+	logFile := filepath.Join(dir, "runs.log")
+
+	source := newPollingSource(time.Hour)
+	defer source.Close()
+	target := &appTarget{name: "crash", app: commandRunner{
+		AppRunner: AppRunner{dir: dir},
+		command:   "echo run >> " + logFile + "; exit 1",
+	}}
+	w := Watcher{
+		apps:          []*appTarget{target},
+		watcher:       source,
+		restartPolicy: RestartOnFailure,
+		maxRetries:    1,
+	}
+	p, err := target.app.Start()
+	if err != nil {
+		t.Fatalf(unexpectedErrorMsg, err)
+	}
+	target.process = p
+	w.supervise(target)
+
+	// exit event, retry event and exit event of the retry
+	for i := 0; i < 3; i++ {
+		if err := w.events(); err != nil {
+			t.Fatalf(unexpectedErrorMsg, err)
+		}
+	}
+	if target.retries != 1 {
+		t.Errorf(assertErrorMsg, 1, target.retries)
+	}
+	if target.retryTimer != nil {
+		t.Error("app should not be restarted after the max retries")
+	}
+	data, err := ioutil.ReadFile(logFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if runs := strings.Count(string(data), "run"); runs != 2 {
+		t.Errorf(assertErrorMsg, 2, runs)
+	}
+}
+
+func TestSuperviseStoppedApp(t *testing.T) {
+	target := &appTarget{app: commandRunner{AppRunner: AppRunner{dir: "."}, command: "exec sleep 30"}}
+	w := Watcher{apps: []*appTarget{target}, restartPolicy: RestartAlways}
+	p, err := target.app.Start()
+	if err != nil {
+		t.Fatalf(unexpectedErrorMsg, err)
+	}
+	target.process = p
+	w.supervise(target)
+	if err := target.stopProcess(p); err != nil {
+		t.Fatalf(unexpectedErrorMsg, err)
+	}
+	select {
+	case exit := <-w.exited:
+		t.Errorf("app stopped by gowatch should not be reported: %v", exit.process)
+	case <-time.After(100 * time.Millisecond):
+	}
+}
+
+//appTestProcess an App running a processTest
+type appTestProcess struct {
+	appTest
+}
+
+func (wa appTestProcess) Start() (Process, error) { return newProcessTest(), nil }
+
+func (wa appTestProcess) Stop(p Process) error {
+	if err := p.Signal(os.Interrupt); err != nil {
+		return err
+	}
+	return p.Wait()
+}
+
+func TestSuperviseRestartedProcess(t *testing.T) {
+	target := &appTarget{name: "custom", app: appTestProcess{}, changes: map[string]bool{"main.go": true}}
+	w := Watcher{apps: []*appTarget{target}, restartPolicy: RestartAlways}
+	p, err := target.app.Start()
+	if err != nil {
+		t.Fatalf(unexpectedErrorMsg, err)
+	}
+	target.process = p
+	w.supervise(target)
+
+	w.restartApp(target)
+	if err := w.restartFinished(<-w.restartDone); err != nil {
+		t.Fatalf(unexpectedErrorMsg, err)
+	}
+	if target.process == nil || target.process == p {
+		t.Error("app should run the process started by the restart")
+	}
+	select {
+	case exit := <-w.exited:
+		t.Errorf("process stopped by the restart should not be reported: %s", describeExit(exit.process))
+	case <-time.After(100 * time.Millisecond):
+	}
+}
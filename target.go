@@ -0,0 +1,310 @@
+package gowatch
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+//AppConfig settings of one of the apps run by the Watcher,
+//used to watch many apps of the same directory at once
+type AppConfig struct {
+	//Name identify the app in the logs and prefix its output
+	Name string
+
+	//Package main package to compile, like ./cmd/api
+	Package string
+
+	//BuildFlags flags to use in go build command
+	BuildFlags []string
+
+	//RunFlags flags to use in the app execution
+	RunFlags []string
+
+	//Ignore patterns of files that don't restart this app
+	Ignore []string
+
+	//Output path of the compiled binary, in the user cache directory if empty
+	Output string
+
+	//BuildCmd command template to compile the app instead of go build
+	BuildCmd string
+
+	//RunCmd command template to run the app instead of the compiled binary
+	RunCmd string
+
+	//Hooks commands run during the lifecycle of the app
+	Hooks Hooks
+}
+
+//appTarget an app run by the Watcher and the state of its restarts
+type appTarget struct {
+	//name of the app, empty when only one app is watched
+	name string
+
+	//interface to start, restart and build the app
+	app App
+
+	//running process of the app
+	process Process
+
+	mu sync.Mutex
+
+	//last process of the app the Watcher asked to stop
+	stopped Process
+
+	//pattern of files that don't restart this app
+	ignore []string
+
+	//directory of the main package of the app, relative to the
+	//watched directory, empty if it is not a local directory
+	pkgDir string
+
+	//package to list the dependencies of, when the app is built by go build
+	depsPkg string
+
+	//build flags of the app used to list its dependencies
+	depsFlags []string
+
+	//list the dependencies of the app to know which changes affect it
+	listDeps bool
+
+	//an auxiliary process that is not restarted by changes
+	auxiliary bool
+
+	//restarts of the app since it crashed
+	retries int
+
+	//fire the restart of the crashed app
+	retryTimer *time.Timer
+
+	//directories of the packages the app is built from, nil if unknown
+	deps map[string]bool
+
+	//files changed since the last restart
+	changes map[string]bool
+
+	//cancel the restart in progress
+	cancelRestart context.CancelFunc
+
+	//hash of the content of the files of the running app
+	hashes map[string]string
+
+	//hash of the changed files not built successfully yet
+	unbuiltHashes map[string]string
+}
+
+//restartResult the result of the restart of target
+type restartResult struct {
+	target *appTarget
+	err    error
+
+	//process running after the restart, nil if the app was
+	//stopped and the new process could not be started
+	process Process
+
+	//the app was built, so the new build has to be started
+	rebuilt bool
+
+	//dependencies of the restarted app
+	deps map[string]bool
+}
+
+//stopProcess stop the process p of the app, recording that
+//it exited because the Watcher asked it
+func (t *appTarget) stopProcess(p Process) error {
+	t.mu.Lock()
+	t.stopped = p
+	t.mu.Unlock()
+	return t.app.Stop(p)
+}
+
+//stoppedByWatcher check if the Watcher asked p to stop
+func (t *appTarget) stoppedByWatcher(p Process) bool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.stopped == p
+}
+
+//restartProcess stop the process p, if any, and start the
+//rebuilt app, returning the process running after it. It is
+//not canceled, once p is stopped the new build is always started
+func (t *appTarget) restartProcess(p Process) (Process, error) {
+	if p != nil {
+		if err := t.stopProcess(p); err != nil {
+			return p, err
+		}
+	}
+	return t.app.Start()
+}
+
+func (t *appTarget) String() string {
+	if t.name == "" {
+		return "app"
+	}
+	return t.name
+}
+
+//newAppTargets create the targets of apps and auxiliary processes, with
+//a single target of the app built from the options if there are no apps
+func newAppTargets(dir string, o options) ([]*appTarget, error) {
+	if o.app != nil && len(o.apps) > 0 {
+		return nil, fmt.Errorf("error to add app: custom app can't be used with many apps")
+	}
+	if len(o.apps) > 0 && !o.hooks.empty() {
+		return nil, fmt.Errorf("error to add app: the hooks of many apps are set in the config of each app")
+	}
+	apps := o.apps
+	if len(apps) == 0 && o.app == nil {
+		apps = []AppConfig{{
+			Package:    o.pkg,
+			BuildFlags: o.buildFlags,
+			RunFlags:   o.runFlags,
+			Output:     o.output,
+			BuildCmd:   o.buildCmd,
+			RunCmd:     o.runCmd,
+			Hooks:      o.hooks,
+		}}
+	}
+	names := make(map[string]bool)
+	targets := make([]*appTarget, 0, len(apps)+len(o.processes)+1)
+	if o.app != nil {
+		// the files of custom apps are unknown, any change restart them
+		names[""] = true
+		targets = append(targets, &appTarget{app: o.app})
+	}
+	for _, config := range apps {
+		if len(apps) > 1 && config.Name == "" {
+			return nil, fmt.Errorf("error to add app: name of the app is required when watching many apps")
+		}
+		if names[config.Name] {
+			return nil, fmt.Errorf("error to add app %q: name already used", config.Name)
+		}
+		names[config.Name] = true
+		for _, command := range []string{config.BuildCmd, config.RunCmd} {
+			if _, err := renderCommand(command, CommandData{}); err != nil {
+				return nil, err
+			}
+		}
+		output := config.Output
+		if output == "" {
+			output = defaultBinaryPath(dir, config.Package)
+			if config.Name != "" {
+				output = filepath.Join(filepath.Dir(output), executableName(config.Name))
+			}
+		}
+		app := AppRunner{
+			name:        config.Name,
+			dir:         dir,
+			runFlags:    config.RunFlags,
+			buildFlags:  config.BuildFlags,
+			pkg:         config.Package,
+			binaryName:  output,
+			keepBinary:  config.Output != "",
+			stopSignal:  o.stopSignal,
+			killTimeout: o.killTimeout,
+			hooks:       config.Hooks,
+			buildCmd:    config.BuildCmd,
+			runCmd:      config.RunCmd,
+			logger:      o.logger,
+		}
+		targets = append(targets, &appTarget{
+			name:   config.Name,
+			app:    app,
+			ignore: config.Ignore,
+			pkgDir: localPackageDir(app.packagePath()),
+			// the dependencies of custom build commands are unknown
+			depsPkg:   app.packagePath(),
+			depsFlags: tagsFlags(config.BuildFlags),
+			listDeps:  config.BuildCmd == "",
+		})
+	}
+	for _, process := range o.processes {
+		if process.Name == "" {
+			return nil, fmt.Errorf("error to add process %q: name of the process is required", process.Command)
+		}
+		if names[process.Name] {
+			return nil, fmt.Errorf("error to add process %q: name already used", process.Name)
+		}
+		names[process.Name] = true
+		if strings.TrimSpace(process.Command) == "" {
+			return nil, fmt.Errorf("error to add process %q: command of the process is required", process.Name)
+		}
+		targets = append(targets, &appTarget{
+			name: process.Name,
+			app: commandRunner{
+				AppRunner: AppRunner{
+					name:        process.Name,
+					dir:         dir,
+					stopSignal:  o.stopSignal,
+					killTimeout: o.killTimeout,
+					logger:      o.logger,
+				},
+				command: process.Command,
+			},
+			auxiliary: true,
+		})
+	}
+	if len(targets) > 1 {
+		prefixOutput(targets, dir)
+	}
+	return targets, nil
+}
+
+//prefixOutput prefix each line written by the apps of targets with
+//their names, in different colors, so they can be told apart
+func prefixOutput(targets []*appTarget, dir string) {
+	width := 0
+	for _, t := range targets {
+		if len(t.displayName(dir)) > width {
+			width = len(t.displayName(dir))
+		}
+	}
+	for i, t := range targets {
+		prefix := colorize(fmt.Sprintf("%-*s |", width, t.displayName(dir)), i) + " "
+		stdout, stderr := newPrefixWriter(os.Stdout, prefix), newPrefixWriter(os.Stderr, prefix)
+		switch app := t.app.(type) {
+		case AppRunner:
+			app.stdout, app.stderr = stdout, stderr
+			t.app = app
+		case commandRunner:
+			app.stdout, app.stderr = stdout, stderr
+			t.app = app
+		}
+	}
+}
+
+//displayName return the name of t, or of the watched directory
+//if t is the only app and has no name
+func (t *appTarget) displayName(dir string) string {
+	if t.name != "" {
+		return t.name
+	}
+	if abs, err := filepath.Abs(dir); err == nil {
+		dir = abs
+	}
+	return getCurrentFolderName(dir)
+}
+
+//localPackageDir return the directory of pkg, as returned by
+//packagePath, or an empty string if pkg is not a directory inside
+//the watched directory
+func localPackageDir(pkg string) string {
+	if !strings.HasPrefix(pkg, ".") {
+		return ""
+	}
+	rel := filepath.Clean(filepath.FromSlash(pkg))
+	if rel == "." || strings.HasPrefix(rel, "..") {
+		return ""
+	}
+	return rel
+}
+
+//inDir check if file, relative to the watched directory, is inside dir
+func inDir(file, dir string) bool {
+	return dir != "" && (file == dir || strings.HasPrefix(file, dir+string(filepath.Separator)))
+}
@@ -0,0 +1,188 @@
+package gowatch
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/fsnotify/fsnotify"
+)
+
+func TestNewAppTargets(t *testing.T) {
+	o := defaultOptions()
+	o.pkg = "./cmd/server"
+	o.buildFlags, o.runFlags = []string{"-v"}, []string{"arg"}
+	targets, err := newAppTargets("./testdata/multiapp", o)
+	if err != nil {
+		t.Fatalf(unexpectedErrorMsg, err)
+	}
+	if len(targets) != 1 {
+		t.Fatalf(assertErrorMsg, 1, len(targets))
+	}
+	app := targets[0].app.(AppRunner)
+	if app.pkg != "./cmd/server" || len(app.buildFlags) != 1 || len(app.runFlags) != 1 {
+		t.Errorf("app should use the options: %+v", app)
+	}
+	if filepath.Base(app.binaryName) != executableName("server") {
+		t.Errorf(assertErrorMsg, executableName("server"), filepath.Base(app.binaryName))
+	}
+	if app.stdout != nil {
+		t.Error("output of a single app should not be prefixed")
+	}
+	if targets[0].pkgDir != filepath.Join("cmd", "server") {
+		t.Errorf(assertErrorMsg, filepath.Join("cmd", "server"), targets[0].pkgDir)
+	}
+}
+
+func TestNewAppTargetsApps(t *testing.T) {
+	o := defaultOptions()
+	WithApps(AppConfig{Name: "api", Package: "./cmd/api"}, AppConfig{Name: "worker", Package: "./cmd/api"})(&o)
+	targets, err := newAppTargets("./testdata/multiapp", o)
+	if err != nil {
+		t.Fatalf(unexpectedErrorMsg, err)
+	}
+	if len(targets) != 2 {
+		t.Fatalf(assertErrorMsg, 2, len(targets))
+	}
+	api, worker := targets[0].app.(AppRunner), targets[1].app.(AppRunner)
+	if api.binaryName == worker.binaryName {
+		t.Errorf("apps should not share binary: %s", api.binaryName)
+	}
+	if api.stdout == nil || api.stderr == nil {
+		t.Error("output of many apps should be prefixed")
+	}
+
+	o = defaultOptions()
+	WithApps(AppConfig{Name: "api"}, AppConfig{})(&o)
+	if _, err := newAppTargets(".", o); err == nil {
+		t.Error("expected error of app without name")
+	}
+
+	o = defaultOptions()
+	WithApps(AppConfig{Name: "api"}, AppConfig{Name: "api"})(&o)
+	if _, err := newAppTargets(".", o); err == nil {
+		t.Error("expected error of duplicated app name")
+	}
+
+	o = defaultOptions()
+	WithApps(AppConfig{Name: "api", RunCmd: "{{.Output"})(&o)
+	if _, err := newAppTargets(".", o); err == nil {
+		t.Error("expected error of invalid run command")
+	}
+}
+
+func TestNewAppTargetsHooks(t *testing.T) {
+	o := defaultOptions()
+	WithApps(
+		AppConfig{Name: "api", Hooks: Hooks{BeforeBuild: []string{"make api"}}},
+		AppConfig{Name: "worker"},
+	)(&o)
+	targets, err := newAppTargets("./testdata/multiapp", o)
+	if err != nil {
+		t.Fatalf(unexpectedErrorMsg, err)
+	}
+	api, worker := targets[0].app.(AppRunner), targets[1].app.(AppRunner)
+	if len(api.hooks.BeforeBuild) != 1 || !worker.hooks.empty() {
+		t.Errorf("each app should run its own hooks: %v %v", api.hooks, worker.hooks)
+	}
+
+	WithHooks(Hooks{BeforeBuild: []string{"go generate ./..."}})(&o)
+	if _, err := newAppTargets("./testdata/multiapp", o); err == nil {
+		t.Error("expected error of hooks shared by many apps")
+	}
+}
+
+func TestNewAppTargetsProcesses(t *testing.T) {
+	o := defaultOptions()
+	WithProcesses(ProcessConfig{Name: "stub", Command: "sleep 30"})(&o)
+	targets, err := newAppTargets("./testdata/multiapp", o)
+	if err != nil {
+		t.Fatalf(unexpectedErrorMsg, err)
+	}
+	if len(targets) != 2 {
+		t.Fatalf(assertErrorMsg, 2, len(targets))
+	}
+	app, stub := targets[0], targets[1]
+	if app.auxiliary || !stub.auxiliary {
+		t.Error("only the process should be auxiliary")
+	}
+	if runner, ok := stub.app.(commandRunner); !ok || runner.command != "sleep 30" {
+		t.Errorf(assertErrorMsg, "commandRunner", stub.app)
+	}
+	if app.app.(AppRunner).stdout == nil || stub.app.(commandRunner).stdout == nil {
+		t.Error("output of the app and the process should be prefixed")
+	}
+	if name := app.displayName("./testdata/multiapp"); name != "multiapp" {
+		t.Errorf(assertErrorMsg, "multiapp", name)
+	}
+
+	w := Watcher{apps: targets}
+	if affected, err := w.affects(stub, "main.go"); err != nil || affected {
+		t.Errorf("process should not be affected by changes: %v", err)
+	}
+
+	for _, process := range []ProcessConfig{{Command: "sleep 30"}, {Name: "stub"}, {Name: "stub", Command: "  "}} {
+		o := defaultOptions()
+		WithProcesses(process)(&o)
+		if _, err := newAppTargets(".", o); err == nil {
+			t.Errorf("expected error of process %v", process)
+		}
+	}
+	o = defaultOptions()
+	WithApps(AppConfig{Name: "api"})(&o)
+	WithProcesses(ProcessConfig{Name: "api", Command: "sleep 30"})(&o)
+	if _, err := newAppTargets(".", o); err == nil {
+		t.Error("expected error of process with the name of an app")
+	}
+}
+
+func TestLocalPackageDir(t *testing.T) {
+	tests := []struct {
+		pkg      string
+		expected string
+	}{
+		{"", ""},
+		{".", ""},
+		{"./cmd/server", filepath.Join("cmd", "server")},
+		{"../other/cmd/server", ""},
+		{"github.com/foo/bar/cmd/server", ""},
+	}
+	for _, test := range tests {
+		if dir := localPackageDir(test.pkg); dir != test.expected {
+			t.Errorf(assertErrorMsg, test.expected, dir)
+		}
+	}
+}
+
+func TestRestartAffectedApps(t *testing.T) {
+	api := &appTarget{name: "api", app: appTest{}, pkgDir: filepath.Join("cmd", "api")}
+	worker := &appTarget{name: "worker", app: appTest{}, pkgDir: filepath.Join("cmd", "worker"), ignore: []string{"*.sql"}}
+	w := Watcher{dir: "/src", apps: []*appTarget{api, worker}, debounce: DefaultDebounce}
+	defer func() {
+		if w.debounceTimer != nil {
+			w.debounceTimer.Stop()
+		}
+	}()
+
+	tests := []struct {
+		file   string
+		api    bool
+		worker bool
+	}{
+		{"/src/internal/db/db.go", true, true},
+		{"/src/cmd/api/main.go", true, false},
+		{"/src/cmd/worker/main.go", false, true},
+		{"/src/migrations/1.sql", true, false},
+	}
+	for _, test := range tests {
+		api.changes, worker.changes = nil, nil
+		if err := w.restart(fsnotify.Event{Name: test.file, Op: fsnotify.Write}); err != nil {
+			t.Fatalf(unexpectedErrorMsg, err)
+		}
+		if api.changes[test.file] != test.api {
+			t.Errorf("%s: "+assertErrorMsg, test.file, test.api, api.changes[test.file])
+		}
+		if worker.changes[test.file] != test.worker {
+			t.Errorf("%s: "+assertErrorMsg, test.file, test.worker, worker.changes[test.file])
+		}
+	}
+}
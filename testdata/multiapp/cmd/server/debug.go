@@ -0,0 +1,8 @@
+//go:build debug
+// +build debug
+
+package main
+
+import "gowatch/testdata/multiapp/internal/debug"
+
+var _ = debug.Enabled()
@@ -0,0 +1,11 @@
+package main
+
+import (
+	"fmt"
+
+	"gowatch/testdata/multiapp/internal/greet"
+)
+
+func main() {
+	fmt.Println(greet.Hello("server"))
+}
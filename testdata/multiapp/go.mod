@@ -0,0 +1,3 @@
+module gowatch/testdata/multiapp
+
+go 1.13
@@ -0,0 +1,6 @@
+package debug
+
+//Enabled report that the app was built with the debug tag
+func Enabled() bool {
+	return true
+}
@@ -0,0 +1,6 @@
+package greet
+
+//Hello return the greeting of name
+func Hello(name string) string {
+	return "hello " + name
+}
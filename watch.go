@@ -1,28 +1,53 @@
 package gowatch
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"os"
-	"os/exec"
 	"path/filepath"
+	"sort"
 	"strings"
+	"sync"
+	"syscall"
+	"time"
 
 	"github.com/fsnotify/fsnotify"
-	"github.com/sirupsen/logrus"
 )
 
 var (
 	//ErrCmdCompile go build command failed to compile program error
 	ErrCmdCompile = errors.New("error to compile program")
 
+	//ErrCmdHook a hook command failed
+	ErrCmdHook = errors.New("error to run hook")
+
 	//ErrInotifyNil nil instance of fsnotify.Watcher
 	ErrInotifyNil = errors.New("inotify instance nil")
 
 	//ErrStopNotifyEvents identify when to stop the watcher
 	ErrStopNotifyEvents = errors.New("stop inotify events")
+
+	//ErrAlreadyRun Run was already called, a Watcher can only run once
+	ErrAlreadyRun = errors.New("watcher already run")
+
+	//ErrClosed Run was called after Close
+	ErrClosed = errors.New("watcher closed")
+
+	//ErrWatchLimit the inotify limit of watched directories was reached
+	ErrWatchLimit = errors.New("inotify watch limit reached, exclude directories with watch_exclude_dirs " +
+		"or ignore patterns, poll for changes with --poll, or increase the limit with sysctl fs.inotify.max_user_watches")
 )
 
+//DefaultDebounce time to wait for more changes before restarting the app
+const DefaultDebounce = 100 * time.Millisecond
+
+//DefaultExtensions extensions of files that restart the app when changed
+var DefaultExtensions = []string{".go"}
+
+//DefaultExcludeDirs name patterns of directories that are not watched
+var DefaultExcludeDirs = []string{".git", "vendor", "node_modules", ".*"}
+
 //Watcher struc to watch  to watch for .go file changes
 type Watcher struct {
 	// directory to watcher for changes
@@ -31,106 +56,435 @@ type Watcher struct {
 	// pattern of files to not watch
 	ignore []string
 
-	//interface to start, restart and build the watched program
-	app App
+	//extensions of files to watch
+	extensions []string
+
+	//pattern of files to watch besides the extensions
+	include []string
+
+	//rules of .gitignore files to not watch, nil if disabled
+	gitignore *gitignore
+
+	//name patterns of directories to not watch
+	excludeDirs []string
+
+	//apps to start, restart and build
+	apps []*appTarget
+
+	//source of the file changes events
+	watcher EventSource
+
+	//directories added to the watcher
+	directories map[string]bool
+
+	//closed to stop watcher events
+	stop chan struct{}
+
+	stopOnce sync.Once
 
-	watcher *fsnotify.Watcher
+	//closed when Run returns
+	done chan struct{}
 
-	//signal to stop watcher events
-	stop chan bool
+	mu sync.Mutex
+
+	//Run was called, or the watcher was closed without running
+	started bool
+
+	//Close was called
+	closed bool
+
+	//time to wait for more changes before restarting the app
+	debounce time.Duration
+
+	//fire when no new changes happened during the debounce time
+	debounceTimer *time.Timer
+
+	//receive the result of the restarts in progress
+	restartDone chan restartResult
+
+	//when to restart apps that exited by themselves
+	restartPolicy RestartPolicy
+
+	//times to restart a crashed app before waiting for changes, 0 is unlimited
+	maxRetries int
+
+	//receive the apps that exited by themselves
+	exited chan processExit
+
+	//receive the crashed apps to start again
+	retry chan *appTarget
+
+	//receive the logs of the watcher, the default logger if nil
+	logger Logger
+
+	//receive the lifecycle events of the apps
+	observers []Observer
 }
 
-//NewWatcher create watcher struct with all values filled
-func NewWatcher(dir string, buildFlags, runFlags, ignore []string) (*Watcher, error) {
-	watcher, err := fsnotify.NewWatcher()
+//NewWatcher create watcher struct with all values filled,
+//it is the same as New with the build flags, run flags and
+//ignore patterns as options
+func NewWatcher(dir string, buildFlags, runFlags, ignore []string, opts ...Option) (*Watcher, error) {
+	return New(dir, append([]Option{
+		WithBuildFlags(buildFlags...),
+		WithRunFlags(runFlags...),
+		WithIgnore(ignore...),
+	}, opts...)...)
+}
+
+//New create a Watcher of the apps of dir configured by opts
+func New(dir string, opts ...Option) (*Watcher, error) {
+	o := defaultOptions()
+	for _, opt := range opts {
+		opt(&o)
+	}
+	if o.debounce < 0 {
+		return nil, fmt.Errorf("invalid debounce %v, use 0 to restart on each change", o.debounce)
+	}
+	apps, err := newAppTargets(dir, o)
+	if err != nil {
+		return nil, err
+	}
+	var gitignoreRules *gitignore
+	if o.gitignore {
+		gitignoreRules = &gitignore{}
+	}
+	watcher, err := newEventSource(o)
 	if err != nil {
 		return nil, err
 	}
 	return &Watcher{
-		ignore:  ignore,
-		dir:     dir,
-		watcher: watcher,
-		stop:    make(chan bool),
-		app: AppRunner{
-			dir:        dir,
-			runFlags:   runFlags,
-			buildFlags: buildFlags,
-			binaryName: getCurrentFolderName(dir),
-		},
+		ignore:      o.ignore,
+		extensions:  o.extensions,
+		include:     o.include,
+		gitignore:   gitignoreRules,
+		excludeDirs: o.excludeDirs,
+		dir:         dir,
+		watcher:     watcher,
+		stop:        make(chan struct{}),
+		done:        make(chan struct{}),
+		debounce:    o.debounce,
+		apps:        apps,
+
+		restartPolicy: o.restartPolicy,
+		maxRetries:    o.maxRetries,
+		logger:        o.logger,
+		observers:     o.observers,
 	}, nil
 }
 
-//Run start the watching for changes  in .go files
-func (w Watcher) Run() error {
-	if err := w.app.Compile(); err != nil {
-		return err
+//newEventSource create the source of file changes events, falling
+//back to polling if the notifications of the operating system fail
+func newEventSource(o options) (EventSource, error) {
+	if o.source != nil {
+		return o.source, nil
 	}
-	cmd, err := w.app.Start()
+	if o.poll {
+		return newPollingSource(o.pollInterval), nil
+	}
+	source, err := newFsnotifySource()
 	if err != nil {
-		return err
+		o.logger.Warnf("Error to watch file system notifications, polling for changes instead: %v\n", err)
+		return newPollingSource(o.pollInterval), nil
 	}
-	if err := w.start(cmd); err != nil {
-		if err := w.shutdown(); err != nil {
-			return fmt.Errorf("Error to shutdown: %v", err)
+	return source, nil
+}
+
+//Run build and start the apps and restart them on changes, until ctx
+//is done or the watcher is stopped. The apps are stopped before Run
+//return, that is nil when it was stopped
+func (w *Watcher) Run(ctx context.Context) error {
+	w.mu.Lock()
+	if w.closed {
+		w.mu.Unlock()
+		return ErrClosed
+	}
+	if w.started {
+		w.mu.Unlock()
+		return ErrAlreadyRun
+	}
+	w.started = true
+	w.mu.Unlock()
+	defer close(w.done)
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	go func() {
+		select {
+		case <-ctx.Done():
+			w.Stop()
+		case <-w.stop:
+			// abort the build of the apps in progress
+			cancel()
+		}
+	}()
+
+	err := w.startApps(ctx)
+	if err == nil {
+		err = w.start()
+	}
+	if stopErr := w.stopApps(); stopErr != nil && err == nil {
+		err = stopErr
+	}
+	if cleanErr := w.clean(); cleanErr != nil && err == nil {
+		err = fmt.Errorf("Error to clean up app: %v", cleanErr)
+	}
+	if shutdownErr := w.shutdown(); shutdownErr != nil && err == nil {
+		err = fmt.Errorf("Error to shutdown: %v", shutdownErr)
+	}
+	if err == ErrStopNotifyEvents || (w.stopped() && errors.Is(err, context.Canceled)) {
+		return nil
+	}
+	return err
+}
+
+//startApps build and start the apps, aborting if ctx is done
+func (w *Watcher) startApps(ctx context.Context) error {
+	for _, t := range w.apps {
+		if err := w.buildApp(ctx, t, t.app.Compile); err != nil {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			return err
+		}
+	}
+	for _, t := range w.apps {
+		p, err := t.app.Start()
+		if err != nil {
+			return err
+		}
+		t.process = p
+		w.supervise(t)
+	}
+	for _, t := range w.apps {
+		t.deps = listDeps(ctx, w.dir, t, w.log())
+	}
+	return nil
+}
+
+//stopApps abort the restarts in progress and stop the running apps
+func (w *Watcher) stopApps() error {
+	for _, t := range w.apps {
+		// the pending changes are not built anymore
+		t.changes = nil
+		if err := w.waitRestarting(t); err != nil {
+			w.appLog(t).Debugf("Restart of %s in progress failed: %v\n", t, err)
+		}
+		t.stopRetry()
+	}
+	for _, t := range w.apps {
+		if t.process == nil {
+			continue
+		}
+		if err := t.stopProcess(t.process); err != nil {
+			return fmt.Errorf("Error to stop %s: %v", t, err)
+		}
+	}
+	return nil
+}
+
+//Stop ask Run to stop watching for changes and the apps running,
+//without waiting for it. Calling Stop many times is safe
+func (w *Watcher) Stop() {
+	w.stopOnce.Do(func() {
+		if w.stop != nil {
+			close(w.stop)
+		}
+	})
+}
+
+//Close stop the watcher and wait for Run to stop the apps and return.
+//If Run was not called it release the resources of the watcher, and
+//a later Run return ErrClosed
+func (w *Watcher) Close() error {
+	w.Stop()
+	w.mu.Lock()
+	started := w.started
+	w.started = true
+	w.closed = true
+	w.mu.Unlock()
+	if started {
+		<-w.done
+		return nil
+	}
+	return w.shutdown()
+}
+
+//buildApp run build to compile the app of t, notifying
+//the observers when the build start and finish
+func (w *Watcher) buildApp(ctx context.Context, t *appTarget, build func(context.Context) error) error {
+	if t.auxiliary {
+		// there is nothing to build
+		return build(ctx)
+	}
+	w.notify(t, Event{Type: BuildStarted})
+	started := time.Now()
+	err := build(ctx)
+	switch {
+	case err == nil:
+		w.notify(t, Event{Type: BuildSucceeded, Duration: time.Since(started)})
+	case ctx.Err() != nil:
+		w.notify(t, Event{Type: BuildCanceled, Duration: time.Since(started)})
+	default:
+		w.notify(t, Event{Type: BuildFailed, Duration: time.Since(started), Output: buildOutput(err), Err: err})
+	}
+	return err
+}
+
+//notify send event of the app of t to the observers
+func (w *Watcher) notify(t *appTarget, event Event) {
+	event.App = t.name
+	notifyObservers(w.observers, event)
+}
+
+//appLog return the logger of the watcher with the name of the app of t
+func (w *Watcher) appLog(t *appTarget) Logger {
+	if t.name == "" {
+		return w.log()
+	}
+	return w.log().With("app", t.name)
+}
+
+func (w *Watcher) log() Logger {
+	if w.logger == nil {
+		return defaultLogger
+	}
+	return w.logger
+}
+
+func (w *Watcher) stopped() bool {
+	select {
+	case <-w.stop:
+		return true
+	default:
+		return false
+	}
+}
+
+//cleaner is implemented by apps that create files that
+//should be removed when gowatch exits
+type cleaner interface {
+	Clean() error
+}
+
+func (w *Watcher) clean() error {
+	for _, t := range w.apps {
+		if c, ok := t.app.(cleaner); ok {
+			if err := c.Clean(); err != nil {
+				return err
+			}
 		}
-		return err
 	}
 	return nil
 }
 
-func (w Watcher) shutdown() error {
-	logrus.Debug("clean up...")
+func (w *Watcher) shutdown() error {
+	w.log().Debugf("clean up...")
 	if w.watcher == nil {
 		return ErrInotifyNil
 	}
 	return w.watcher.Close()
 }
 
-func (w Watcher) isToIgnoreFile(file string) (bool, error) {
-	for _, pattern := range w.ignore {
-		matched, err := filepath.Match(pattern, file)
-		if err != nil {
-			return true, err
-		}
-		if matched {
-			return matched, nil
-		}
+//isToIgnoreFile check if file, relative to the
+//watched directory, match one of the ignore patterns
+func (w *Watcher) isToIgnoreFile(file string) (bool, error) {
+	return w.isToIgnore(file, false)
+}
+
+//isToIgnoreDir check if dir, relative to the watched
+//directory, match one of the ignore patterns
+func (w *Watcher) isToIgnoreDir(dir string) (bool, error) {
+	return w.isToIgnore(dir, true)
+}
+
+func (w *Watcher) isToIgnore(file string, isDir bool) (bool, error) {
+	rel := w.relativePath(file)
+	matched, err := matchAny(w.ignore, rel)
+	if err != nil {
+		return true, err
+	}
+	return matched || w.gitignore.ignored(rel, isDir), nil
+}
+
+//isToWatchFile check if file has one of the watched
+//extensions or match one of the include patterns
+func (w *Watcher) isToWatchFile(file string) (bool, error) {
+	extensions := w.extensions
+	if len(extensions) == 0 {
+		extensions = DefaultExtensions
+	}
+	if ext := filepath.Ext(file); ext != "" && contains(extensions, ext) {
+		return true, nil
+	}
+	return matchAny(w.include, w.relativePath(file))
+}
+
+//relativePath return file relative to the watched directory
+func (w *Watcher) relativePath(file string) string {
+	if w.dir == "" || filepath.IsAbs(file) != filepath.IsAbs(w.dir) {
+		return file
 	}
-	return false, nil
+	rel, err := filepath.Rel(w.dir, file)
+	if err != nil {
+		return file
+	}
+	return rel
 }
 
-func (w *Watcher) events(cmd *exec.Cmd) error {
+func (w *Watcher) events() error {
 	select {
 
 	case <-w.stop:
 		return ErrStopNotifyEvents
 
-	case event, ok := <-w.watcher.Events:
+	case event, ok := <-w.watcher.Events():
 		if !ok {
 			return nil
 		}
+		if event.Op&(fsnotify.Remove|fsnotify.Rename) != 0 {
+			if w.removeDirectories(event.Name) {
+				w.log().Debugf("removed directory: %s\n", event.Name)
+				return nil
+			}
+		}
 		if event.Op&fsnotify.Create == fsnotify.Create {
-			newDirectories, err := discoverSubDirectories(event.Name)
+			newDirectories, err := w.discoverSubDirectories(event.Name)
 			if err != nil {
+				// the file was removed before we could look at it
+				if os.IsNotExist(err) {
+					return nil
+				}
 				return err
 			}
-			logrus.Debugf("find new directories: %v\n", newDirectories)
-			if err := w.addDirectories(newDirectories...); err != nil {
-				return err
+			if len(newDirectories) != 0 {
+				w.log().Debugf("find new directories: %v\n", newDirectories)
+				return w.addDirectories(newDirectories...)
 			}
-			return nil
 		}
-		if event.Op&fsnotify.Write == fsnotify.Write {
-			if event.Name[len(event.Name)-3:] == ".go" {
-				if err := w.restart(cmd, event); err != nil {
-					if !errors.Is(err, ErrCmdCompile) {
-						return err
-					}
-				}
+		if event.Op&(fsnotify.Create|fsnotify.Write|fsnotify.Remove|fsnotify.Rename) != 0 {
+			watch, err := w.isToWatchFile(event.Name)
+			if err != nil {
+				return err
+			}
+			if watch {
+				return w.restart(event)
 			}
 		}
 
-	case err, ok := <-w.watcher.Errors:
+	case <-w.debounced():
+		return w.restartApps()
+
+	case result := <-w.restartDone:
+		return w.restartFinished(result)
+
+	case exit := <-w.exited:
+		return w.processExited(exit)
+
+	case t := <-w.retry:
+		return w.retryApp(t)
+
+	case err, ok := <-w.watcher.Errors():
 		if !ok {
 			return fmt.Errorf("watcher files changes error: %v", err)
 		}
@@ -139,42 +493,297 @@ func (w *Watcher) events(cmd *exec.Cmd) error {
 	return nil
 }
 
-func (w Watcher) start(cmd *exec.Cmd) error {
-	directories, err := discoverSubDirectories(w.dir)
+func (w *Watcher) start() error {
+	directories, err := w.discoverSubDirectories(w.dir)
 	if err != nil {
 		return err
 	}
 	if err := w.addDirectories(directories...); err != nil {
 		return err
 	}
+	if err := w.hashDirectories(directories...); err != nil {
+		return err
+	}
 	for {
-		if err := w.events(cmd); err != nil {
+		if err := w.events(); err != nil {
 			return err
 		}
 	}
 }
 
-func (w Watcher) addDirectories(directories ...string) error {
+func (w *Watcher) addDirectories(directories ...string) error {
+	if w.directories == nil {
+		w.directories = make(map[string]bool)
+	}
 	for _, d := range directories {
 		if err := w.watcher.Add(d); err != nil {
-			return err
+			return watchError(d, err)
 		}
+		w.directories[d] = true
 	}
 	return nil
 }
 
-func (w Watcher) restart(cmd *exec.Cmd, event fsnotify.Event) error {
+//watchError explain the error of adding dir to the watcher
+func watchError(dir string, err error) error {
+	if errors.Is(err, syscall.ENOSPC) {
+		return fmt.Errorf("error to watch %s: %w", dir, ErrWatchLimit)
+	}
+	return err
+}
+
+//removeDirectories stop watching dir and its subdirectories,
+//return false if dir was not a watched directory
+func (w *Watcher) removeDirectories(dir string) bool {
+	removed := false
+	for d := range w.directories {
+		if d == dir || strings.HasPrefix(d, dir+string(filepath.Separator)) {
+			// the watch is already gone when the directory is deleted
+			_ = w.watcher.Remove(d)
+			delete(w.directories, d)
+			removed = true
+		}
+	}
+	return removed
+}
+
+func (w *Watcher) restart(event fsnotify.Event) error {
 	ignore, err := w.isToIgnoreFile(event.Name)
 	if err != nil {
 		return err
 	}
-	if !ignore {
-		logrus.Debugf("Modified file: %s\n", event.Name)
-		return w.app.Restart(cmd)
+	if ignore {
+		return nil
 	}
+	w.log().Debugf("Modified file: %s\n", event.Name)
+
+	changed := false
+	for _, t := range w.apps {
+		affected, err := w.affects(t, event.Name)
+		if err != nil {
+			return err
+		}
+		if !affected {
+			continue
+		}
+		// a build of older changes is useless now, abort it
+		w.cancelRestarting(t)
+		t.stopRetry()
+		if t.changes == nil {
+			t.changes = make(map[string]bool)
+		}
+		t.changes[event.Name] = true
+		changed = true
+	}
+	if !changed {
+		return nil
+	}
+	return w.scheduleRestart()
+}
+
+//scheduleRestart restart the apps with pending changes
+//once no change arrive for the debounce time
+func (w *Watcher) scheduleRestart() error {
+	if w.debounce <= 0 {
+		return w.restartApps()
+	}
+	if w.debounceTimer == nil {
+		w.debounceTimer = time.NewTimer(w.debounce)
+		return nil
+	}
+	if !w.debounceTimer.Stop() {
+		select {
+		case <-w.debounceTimer.C:
+		default:
+		}
+	}
+	w.debounceTimer.Reset(w.debounce)
 	return nil
 }
 
+//affects check if a change of file restart the app of t, auxiliary
+//processes are never restarted by changes. When the
+//dependencies of the app are known only the .go files of its packages
+//affect it, otherwise files inside the package of another app only
+//affect that app
+func (w *Watcher) affects(t *appTarget, file string) (bool, error) {
+	if t.auxiliary {
+		return false, nil
+	}
+	rel := w.relativePath(file)
+	ignore, err := matchAny(t.ignore, rel)
+	if err != nil || ignore {
+		return false, err
+	}
+	if t.deps != nil && !isModuleFile(file) {
+		if strings.HasSuffix(file, "_test.go") {
+			return false, nil
+		}
+		if dir, err := filepath.Abs(filepath.Dir(file)); err == nil && t.deps[dir] {
+			return true, nil
+		}
+		if filepath.Ext(file) == ".go" {
+			return false, nil
+		}
+	}
+	if inDir(rel, t.pkgDir) {
+		return true, nil
+	}
+	for _, other := range w.apps {
+		if other != t && inDir(rel, other.pkgDir) {
+			return false, nil
+		}
+	}
+	return true, nil
+}
+
+//debounced return a channel that fire when the debounce time
+//of the pending changes ends, or nil if there is no changes
+func (w *Watcher) debounced() <-chan time.Time {
+	if w.debounceTimer == nil || !w.hasChanges() {
+		return nil
+	}
+	return w.debounceTimer.C
+}
+
+func (w *Watcher) hasChanges() bool {
+	for _, t := range w.apps {
+		if len(t.changes) != 0 {
+			return true
+		}
+	}
+	return false
+}
+
+//restarting check if the restart of any app is in progress
+func (w *Watcher) restarting() bool {
+	for _, t := range w.apps {
+		if t.cancelRestart != nil {
+			return true
+		}
+	}
+	return false
+}
+
+//restartApps restart each app once for all its pending changes, the
+//apps with a canceled restart in progress are restarted when it finish
+func (w *Watcher) restartApps() error {
+	for _, t := range w.apps {
+		if len(t.changes) != 0 && t.cancelRestart == nil {
+			w.restartApp(t)
+		}
+	}
+	return nil
+}
+
+//restartApp restart the app of t in background
+func (w *Watcher) restartApp(t *appTarget) {
+	files := make([]string, 0, len(t.changes))
+	for file := range t.changes {
+		files = append(files, file)
+	}
+	sort.Strings(files)
+	t.changes = nil
+
+	if !t.contentChanged(files, w.log()) {
+		w.appLog(t).With("files", files).Debugf("Content of %v did not change, skipping restart of %s\n", files, t)
+		return
+	}
+
+	w.appLog(t).With("files", files).Infof("Restarting %s due to changes in: %v\n", t, files)
+	w.notify(t, Event{Type: FileChanged, Files: files})
+	if w.restartDone == nil {
+		// each app has at most one restart in progress
+		w.restartDone = make(chan restartResult, len(w.apps))
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	t.cancelRestart = cancel
+	go func(done chan<- restartResult, dir string, p Process, logger Logger) {
+		defer cancel()
+		result := restartResult{target: t, process: p}
+		if result.err = w.buildApp(ctx, t, t.app.Rebuild); result.err == nil {
+			result.err = ctx.Err()
+		}
+		if result.err == nil {
+			result.rebuilt = true
+			result.process, result.err = t.restartProcess(p)
+		}
+		if result.err == nil {
+			// the imports may have changed, the app is already
+			// restarted so a newer change does not cancel it
+			result.deps = listDeps(context.Background(), dir, t, logger)
+		}
+		done <- result
+	}(w.restartDone, w.dir, t.process, w.log())
+}
+
+//cancelRestarting abort the restart in progress of t, if any, without
+//waiting for it. The restart stay in progress until its result arrive
+func (w *Watcher) cancelRestarting(t *appTarget) {
+	if t.cancelRestart == nil {
+		return
+	}
+	w.appLog(t).Debugf("Canceling restart of %s in progress\n", t)
+	t.cancelRestart()
+}
+
+//waitRestarting abort the restart in progress of t, if any,
+//and wait for it to finish
+func (w *Watcher) waitRestarting(t *appTarget) error {
+	if t.cancelRestart == nil {
+		return nil
+	}
+	w.cancelRestarting(t)
+	for {
+		result := <-w.restartDone
+		err := w.restartFinished(result)
+		if result.target == t || err != nil {
+			return err
+		}
+	}
+}
+
+//restartFinished handle the result of a restart, restarting
+//again the app if changes arrived while it was in progress
+func (w *Watcher) restartFinished(result restartResult) error {
+	t := result.target
+	t.cancelRestart = nil
+	t.process = result.process
+	err := restartError(result.err)
+	switch {
+	case result.err == nil:
+		t.commitHashes()
+		t.deps = result.deps
+		t.retries = 0
+		w.supervise(t)
+	case result.rebuilt && result.process == nil:
+		// the old process is already stopped, keep trying
+		// to start the new build instead of leaving it down
+		w.appLog(t).Errorf("Error to start %s: %v\n", t, result.err)
+		t.commitHashes()
+		t.deps = nil
+		w.scheduleRetry(t)
+		err = nil
+	case !errors.Is(result.err, context.Canceled):
+		// the failed build may import packages not created yet,
+		// so any change restart the app until it is built again
+		t.deps = nil
+	}
+	if err == nil && len(t.changes) != 0 {
+		return w.scheduleRestart()
+	}
+	return err
+}
+
+//restartError filter the errors of a restart that
+//should not stop the watcher
+func restartError(err error) error {
+	if err == nil || errors.Is(err, ErrCmdCompile) || errors.Is(err, ErrCmdHook) || errors.Is(err, context.Canceled) {
+		return nil
+	}
+	return err
+}
+
 func contains(list []string, value string) bool {
 	for _, n := range list {
 		if value == n {
@@ -184,15 +793,43 @@ func contains(list []string, value string) bool {
 	return false
 }
 
-func discoverSubDirectories(baseDir string) ([]string, error) {
+//discoverSubDirectories find baseDir and its subdirectories,
+//skipping the directories that match the ignore patterns
+//and loading the .gitignore files found
+func (w *Watcher) discoverSubDirectories(baseDir string) ([]string, error) {
 	directories := []string{}
 	if err := filepath.Walk(baseDir, func(path string, info os.FileInfo, err error) error {
 		if err != nil {
 			return err
 		}
-		if info.IsDir() {
-			directories = append(directories, path)
+		if !info.IsDir() {
+			return nil
+		}
+		rel := w.relativePath(path)
+		if rel != "." {
+			exclude, err := matchAny(w.excludeDirs, info.Name())
+			if err != nil {
+				return err
+			}
+			if exclude {
+				w.log().Debugf("Excluding directory %s\n", path)
+				return filepath.SkipDir
+			}
+			ignore, err := w.isToIgnoreDir(path)
+			if err != nil {
+				return err
+			}
+			if ignore {
+				w.log().Debugf("Ignoring directory %s\n", path)
+				return filepath.SkipDir
+			}
+		}
+		if w.gitignore != nil {
+			if err := w.gitignore.load(path, rel); err != nil {
+				return err
+			}
 		}
+		directories = append(directories, path)
 		return nil
 	}); err != nil {
 		return nil, err
@@ -1,14 +1,19 @@
 package gowatch
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"io/ioutil"
 	"os"
-	"os/exec"
+	"path/filepath"
+	"strings"
+	"syscall"
 	"testing"
+	"time"
 
 	"github.com/fsnotify/fsnotify"
+	"github.com/sirupsen/logrus"
 )
 
 var (
@@ -19,19 +24,57 @@ var (
 
 type appTestCompileError struct{}
 
-func (wa appTestCompileError) Compile() error            { return nil }
-func (wa appTestCompileError) Start() (*exec.Cmd, error) { return nil, nil }
-func (wa appTestCompileError) Stop(cmd *exec.Cmd) error  { return nil }
-func (wa appTestCompileError) Restart(cmd *exec.Cmd) error {
+func (wa appTestCompileError) Compile(ctx context.Context) error { return nil }
+func (wa appTestCompileError) Start() (Process, error)           { return nil, nil }
+func (wa appTestCompileError) Stop(p Process) error              { return nil }
+func (wa appTestCompileError) Rebuild(ctx context.Context) error {
 	return errProgramShoultNotRestartTest
 }
 
 type appTest struct{}
 
-func (wa appTest) Compile() error              { return nil }
-func (wa appTest) Start() (*exec.Cmd, error)   { return nil, nil }
-func (wa appTest) Stop(cmd *exec.Cmd) error    { return nil }
-func (wa appTest) Restart(cmd *exec.Cmd) error { return nil }
+func (wa appTest) Compile(ctx context.Context) error { return nil }
+func (wa appTest) Start() (Process, error)           { return nil, nil }
+func (wa appTest) Stop(p Process) error              { return nil }
+func (wa appTest) Rebuild(ctx context.Context) error { return nil }
+
+type appTestCounter struct {
+	restarts int
+}
+
+func (wa *appTestCounter) Compile(ctx context.Context) error { return nil }
+func (wa *appTestCounter) Start() (Process, error)           { return nil, nil }
+func (wa *appTestCounter) Stop(p Process) error              { return nil }
+func (wa *appTestCounter) Rebuild(ctx context.Context) error {
+	wa.restarts++
+	return nil
+}
+
+//appTestSlowBuild block the build until it is canceled
+type appTestSlowBuild struct {
+	appTest
+	canceled chan bool
+}
+
+func (wa appTestSlowBuild) Rebuild(ctx context.Context) error {
+	<-ctx.Done()
+	wa.canceled <- true
+	return ctx.Err()
+}
+
+//appTestStartError an App whose new build does not start
+type appTestStartError struct {
+	appTest
+}
+
+func (wa appTestStartError) Start() (Process, error) {
+	return nil, ErrCmdHook
+}
+
+//testApps return the targets of the Watcher with a single app
+func testApps(app App) []*appTarget {
+	return []*appTarget{{app: app}}
+}
 
 func createTmpDir(prefix string) (string, error) {
 	dir, err := ioutil.TempDir("", prefix)
@@ -52,19 +95,103 @@ func TestRun(t *testing.T) {
 	}
 	errCh := make(chan error)
 	go func() {
-		errCh <- w.Run()
+		errCh <- w.Run(context.Background())
 	}()
-	//signal to stop events function
-	w.stop <- true
+	w.Stop()
 	if err := <-errCh; err != nil {
-		if err != ErrStopNotifyEvents {
-			t.Fatalf(unexpectedErrorMsg, err)
+		t.Fatalf(unexpectedErrorMsg, err)
+	}
+	// stop many times is safe
+	w.Stop()
+}
+
+func TestClose(t *testing.T) {
+	w, err := NewWatcher("./testdata/helloworld/", nil, nil, nil)
+	if err != nil {
+		t.Fatalf(unexpectedErrorMsg, err)
+	}
+	errCh := make(chan error, 1)
+	go func() {
+		errCh <- w.Run(context.Background())
+	}()
+	if err := w.Close(); err != nil {
+		t.Fatalf(unexpectedErrorMsg, err)
+	}
+	// Run return ErrClosed if Close happened before it started
+	select {
+	case err := <-errCh:
+		if err != nil && err != ErrClosed {
+			t.Errorf(unexpectedErrorMsg, err)
 		}
+	case <-time.After(5 * time.Second):
+		t.Error("Run should return after Close")
+	}
+}
+
+func TestCloseWithoutRun(t *testing.T) {
+	w, err := NewWatcher("./testdata/helloworld/", nil, nil, nil)
+	if err != nil {
+		t.Fatalf(unexpectedErrorMsg, err)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatalf(unexpectedErrorMsg, err)
+	}
+	if err := w.watcher.Add("/tmp/"); err == nil {
+		t.Error("expected error of inotify instance already closed")
+	}
+	if err := w.Run(context.Background()); err != ErrClosed {
+		t.Errorf(assertErrorMsg, ErrClosed, err)
+	}
+}
+
+func TestNew(t *testing.T) {
+	source := newPollingSource(time.Second)
+	l := logrus.New()
+	l.SetOutput(ioutil.Discard)
+	logger := NewLogrusLogger(l)
+	w, err := New("./testdata/helloworld/",
+		WithApp(appTest{}),
+		WithEventSource(source),
+		WithIgnore("*_test.go"),
+		WithLogger(logger),
+		WithDebounce(time.Second),
+	)
+	if err != nil {
+		t.Fatalf(unexpectedErrorMsg, err)
+	}
+	defer w.Close()
+	if len(w.apps) != 1 || w.apps[0].app != (appTest{}) {
+		t.Errorf(assertErrorMsg, "appTest", w.apps)
+	}
+	if w.watcher != source {
+		t.Errorf(assertErrorMsg, source, w.watcher)
+	}
+	if len(w.ignore) != 1 || w.ignore[0] != "*_test.go" {
+		t.Errorf(assertErrorMsg, []string{"*_test.go"}, w.ignore)
+	}
+	if w.log() != logger {
+		t.Errorf(assertErrorMsg, logger, w.log())
+	}
+	if w.debounce != time.Second {
+		t.Errorf(assertErrorMsg, time.Second, w.debounce)
+	}
+
+	if _, err := New("./testdata/helloworld/", WithApp(appTest{}), WithApps(AppConfig{Name: "api"})); err == nil {
+		t.Error("expected error of custom app with many apps")
+	}
+}
+
+func TestNewWatcherInvalidCommand(t *testing.T) {
+	if _, err := NewWatcher("./testdata/helloworld/", nil, nil, nil, WithBuildCmd("go build -o {{.Output")); err == nil {
+		t.Error("expected error to parse an invalid build command")
+	}
+	if _, err := NewWatcher("./testdata/helloworld/", nil, nil, nil, WithRunCmd("{{.Output")); err == nil {
+		t.Error("expected error to parse an invalid run command")
 	}
 }
 
 func TestAddNewDirectories(t *testing.T) {
-	w, err := fsnotify.NewWatcher()
+	w, err := newFsnotifySource()
 	if err != nil {
 		t.Fatalf(unexpectedErrorMsg, err)
 	}
@@ -90,13 +217,13 @@ func TestAddNewDirectories(t *testing.T) {
 }
 
 func TestEventsRestart(t *testing.T) {
-	w, err := fsnotify.NewWatcher()
+	w, err := newFsnotifySource()
 	if err != nil {
 		t.Fatal(err)
 	}
 
 	watcher := Watcher{
-		app:     appTestCompileError{},
+		apps:    testApps(appTestCompileError{}),
 		watcher: w,
 	}
 
@@ -115,7 +242,7 @@ func TestEventsRestart(t *testing.T) {
 		t.Fatalf(unexpectedErrorMsg, err)
 	}
 	// create new dir event
-	if err := watcher.events(nil); err != nil {
+	if err := watcher.events(); err != nil {
 		t.Errorf(unexpectedErrorMsg, err)
 	}
 
@@ -131,7 +258,7 @@ func TestEventsRestart(t *testing.T) {
 	t.Logf("Create tmp file: %s\n", tmpFile.Name())
 
 	// create file event
-	if err := watcher.events(nil); err != nil {
+	if err := watcher.events(); err != nil {
 		t.Errorf(unexpectedErrorMsg, err)
 	}
 	if _, err := tmpFile.Write([]byte(`package main
@@ -140,7 +267,7 @@ func TestEventsRestart(t *testing.T) {
 	}
 
 	// write event
-	if err := watcher.events(nil); err != nil {
+	if err := watcher.events(); err != nil {
 		if !errors.Is(err, errProgramShoultNotRestartTest) {
 			t.Errorf(unexpectedErrorMsg, err)
 		}
@@ -148,8 +275,156 @@ func TestEventsRestart(t *testing.T) {
 
 }
 
+func TestEventsRemoveAndRename(t *testing.T) {
+	w, err := newFsnotifySource()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer w.Close()
+
+	dir, err := createTmpDir("TestEventsRemoveAndRename")
+	if err != nil {
+		t.Fatalf(unexpectedErrorMsg, err)
+	}
+	defer os.RemoveAll(dir)
+
+	tmpFile, err := createTmpGoFile(dir, "main.go")
+	if err != nil {
+		t.Fatalf(unexpectedErrorMsg, err)
+	}
+	tmpFile.Close()
+
+	app := &appTestCounter{}
+	watcher := Watcher{apps: testApps(app), watcher: w}
+	if err := watcher.addDirectories(dir); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := os.Rename(tmpFile.Name(), tmpFile.Name()+".bak"); err != nil {
+		t.Fatal(err)
+	}
+	// rename event
+	if err := watcher.events(); err != nil {
+		t.Fatalf(unexpectedErrorMsg, err)
+	}
+	// create event of not watched file and restart finished event
+	for watcher.restarting() {
+		if err := watcher.events(); err != nil {
+			t.Fatalf(unexpectedErrorMsg, err)
+		}
+	}
+	if app.restarts != 1 {
+		t.Fatalf(assertErrorMsg, 1, app.restarts)
+	}
+
+	if err := os.Remove(tmpFile.Name() + ".bak"); err != nil {
+		t.Fatal(err)
+	}
+	// remove event of not watched file
+	if err := watcher.events(); err != nil {
+		t.Fatalf(unexpectedErrorMsg, err)
+	}
+	if app.restarts != 1 {
+		t.Errorf(assertErrorMsg, 1, app.restarts)
+	}
+}
+
+func TestEventsRemoveDirectory(t *testing.T) {
+	w, err := newFsnotifySource()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer w.Close()
+
+	baseDir, err := createTmpDir("TestEventsRemoveDirectory")
+	if err != nil {
+		t.Fatalf(unexpectedErrorMsg, err)
+	}
+	defer os.RemoveAll(baseDir)
+	dir, err := ioutil.TempDir(baseDir, "secondDir")
+	if err != nil {
+		t.Fatalf(unexpectedErrorMsg, err)
+	}
+
+	watcher := Watcher{apps: testApps(appTestCompileError{}), watcher: w}
+	if err := watcher.addDirectories(baseDir, dir); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Remove(dir); err != nil {
+		t.Fatal(err)
+	}
+	// remove events of the directory itself and from the parent directory
+	for i := 0; i < 2; i++ {
+		if err := watcher.events(); err != nil {
+			t.Fatalf(unexpectedErrorMsg, err)
+		}
+	}
+	if watcher.directories[dir] {
+		t.Errorf("%s should not be watched anymore", dir)
+	}
+	if !watcher.directories[baseDir] {
+		t.Errorf("%s should be watched", baseDir)
+	}
+}
+
+func TestRemoveDirectories(t *testing.T) {
+	w, err := newFsnotifySource()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer w.Close()
+	watcher := Watcher{
+		watcher: w,
+		directories: map[string]bool{
+			"/tmp/foo":     true,
+			"/tmp/foo/bar": true,
+			"/tmp/foobar":  true,
+		},
+	}
+	if !watcher.removeDirectories("/tmp/foo") {
+		t.Error("/tmp/foo should be removed")
+	}
+	if len(watcher.directories) != 1 || !watcher.directories["/tmp/foobar"] {
+		t.Errorf(assertErrorMsg, "[/tmp/foobar]", watcher.directories)
+	}
+	if watcher.removeDirectories("/tmp/foo/main.go") {
+		t.Error("/tmp/foo/main.go is not a directory")
+	}
+}
+
+func TestEventsPolling(t *testing.T) {
+	dir, err := createTmpDir("TestEventsPolling")
+	if err != nil {
+		t.Fatalf(unexpectedErrorMsg, err)
+	}
+	defer os.RemoveAll(dir)
+
+	source := newPollingSource(10 * time.Millisecond)
+	defer source.Close()
+	app := &appTestCounter{}
+	watcher := Watcher{apps: testApps(app), watcher: source}
+	if err := watcher.addDirectories(dir); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := createTmpGoFile(dir, "main.go"); err != nil {
+		t.Fatal(err)
+	}
+	// create event
+	if err := watcher.events(); err != nil {
+		t.Fatalf(unexpectedErrorMsg, err)
+	}
+	// restart finished event
+	if err := watcher.events(); err != nil {
+		t.Fatalf(unexpectedErrorMsg, err)
+	}
+	if app.restarts != 1 {
+		t.Errorf(assertErrorMsg, 1, app.restarts)
+	}
+}
+
 func TestEvents(t *testing.T) {
-	w, err := fsnotify.NewWatcher()
+	w, err := newFsnotifySource()
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -173,7 +448,7 @@ func TestEvents(t *testing.T) {
 	}
 
 	// create new dir event
-	if err := watcher.events(nil); err != nil {
+	if err := watcher.events(); err != nil {
 		t.Errorf(unexpectedErrorMsg, err)
 	}
 
@@ -184,7 +459,7 @@ func TestEvents(t *testing.T) {
 	t.Logf("Create tmp file: %s\n", tmpFile.Name())
 
 	// create file event
-	if err := watcher.events(nil); err != nil {
+	if err := watcher.events(); err != nil {
 		t.Errorf(unexpectedErrorMsg, err)
 	}
 	if _, err := tmpFile.Write([]byte(`package main
@@ -193,21 +468,21 @@ func TestEvents(t *testing.T) {
 	}
 
 	// write event
-	if err := watcher.events(nil); err != nil {
+	if err := watcher.events(); err != nil {
 		t.Errorf(unexpectedErrorMsg, err)
 	}
 }
 
 func TestRestart(t *testing.T) {
 	w := Watcher{
-		dir: "./testdata/http-server",
-		app: appTest{},
+		dir:  "./testdata/http-server",
+		apps: testApps(appTest{}),
 	}
 	event := fsnotify.Event{
 		Name: "main.go",
 	}
 
-	if err := w.restart(&exec.Cmd{}, event); err != nil {
+	if err := w.restart(event); err != nil {
 		t.Fatalf(unexpectedErrorMsg, err)
 	}
 }
@@ -216,15 +491,93 @@ func TestRestartIgnore(t *testing.T) {
 	w := Watcher{
 		dir:    "./testdata/http-server",
 		ignore: []string{"main.go"},
-		app:    appTestCompileError{},
+		apps:   testApps(appTestCompileError{}),
 	}
 	event := fsnotify.Event{
 		Name: "main.go",
 	}
 
-	if err := w.restart(&exec.Cmd{}, event); err != nil {
+	if err := w.restart(event); err != nil {
+		t.Fatalf(unexpectedErrorMsg, err)
+	}
+}
+
+func TestRestartDebounce(t *testing.T) {
+	watcher, err := newFsnotifySource()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer watcher.Close()
+
+	app := &appTestCounter{}
+	w := Watcher{
+		apps:     testApps(app),
+		watcher:  watcher,
+		debounce: 50 * time.Millisecond,
+	}
+	for _, file := range []string{"main.go", "foo.go", "main.go"} {
+		if err := w.restart(fsnotify.Event{Name: file}); err != nil {
+			t.Fatalf(unexpectedErrorMsg, err)
+		}
+	}
+	if app.restarts != 0 {
+		t.Fatalf(assertErrorMsg, 0, app.restarts)
+	}
+	if len(w.apps[0].changes) != 2 {
+		t.Errorf(assertErrorMsg, 2, len(w.apps[0].changes))
+	}
+
+	// debounce timer event
+	if err := w.events(); err != nil {
 		t.Fatalf(unexpectedErrorMsg, err)
 	}
+	// restart finished event
+	if err := w.events(); err != nil {
+		t.Fatalf(unexpectedErrorMsg, err)
+	}
+	if app.restarts != 1 {
+		t.Errorf(assertErrorMsg, 1, app.restarts)
+	}
+	if len(w.apps[0].changes) != 0 {
+		t.Errorf(assertErrorMsg, 0, len(w.apps[0].changes))
+	}
+}
+
+func TestRestartCancelBuild(t *testing.T) {
+	app := appTestSlowBuild{canceled: make(chan bool, 1)}
+	w := Watcher{apps: testApps(app)}
+
+	if err := w.restart(fsnotify.Event{Name: "main.go"}); err != nil {
+		t.Fatalf(unexpectedErrorMsg, err)
+	}
+	if !w.restarting() {
+		t.Fatal("restart should be in progress")
+	}
+
+	// a newer change abort the build in progress, without waiting for it
+	if err := w.restart(fsnotify.Event{Name: "foo.go"}); err != nil {
+		t.Fatalf(unexpectedErrorMsg, err)
+	}
+	select {
+	case <-app.canceled:
+	case <-time.After(5 * time.Second):
+		t.Error("build in progress should be canceled")
+	}
+
+	// the app is restarted again when the canceled restart finish
+	if err := w.restartFinished(<-w.restartDone); err != nil {
+		t.Fatalf(unexpectedErrorMsg, err)
+	}
+	if !w.restarting() {
+		t.Error("a new restart should be in progress")
+	}
+
+	if err := w.waitRestarting(w.apps[0]); err != nil {
+		t.Fatalf(unexpectedErrorMsg, err)
+	}
+	if w.restarting() {
+		t.Error("no restart should be in progress")
+	}
 }
 
 func TestShutdowNil(t *testing.T) {
@@ -235,7 +588,7 @@ func TestShutdowNil(t *testing.T) {
 }
 
 func TestShutdow(t *testing.T) {
-	watcher, err := fsnotify.NewWatcher()
+	watcher, err := newFsnotifySource()
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -267,6 +620,67 @@ func TestIsToIgnoreFile(t *testing.T) {
 		t.Errorf("main.go should not match with pattern *_test.go")
 	}
 
+	w = Watcher{
+		dir:    "/home/unittest/gowatch",
+		ignore: []string{"*_test.go", "vendor/**"},
+	}
+	for file, expected := range map[string]bool{
+		"/home/unittest/gowatch/foo/main_test.go":      true,
+		"/home/unittest/gowatch/vendor/foo/bar/foo.go": true,
+		"/home/unittest/gowatch/foo/vendor/foo.go":     false,
+		"/home/unittest/gowatch/main.go":               false,
+	} {
+		matched, err := w.isToIgnoreFile(file)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if matched != expected {
+			t.Errorf("%s: "+assertErrorMsg, file, expected, matched)
+		}
+	}
+
+}
+
+func TestIsToWatchFile(t *testing.T) {
+	w := Watcher{}
+	for file, expected := range map[string]bool{
+		"main.go":         true,
+		"index.tmpl":      false,
+		"go.mod":          false,
+		"a":               false,
+		"/tmp/foo/bar.go": true,
+	} {
+		watch, err := w.isToWatchFile(file)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if watch != expected {
+			t.Errorf("%s: "+assertErrorMsg, file, expected, watch)
+		}
+	}
+
+	w = Watcher{
+		dir:        "/home/unittest/gowatch",
+		extensions: []string{".go", ".tmpl"},
+		include:    []string{"go.mod", "migrations/*.sql"},
+	}
+	for file, expected := range map[string]bool{
+		"/home/unittest/gowatch/main.go":               true,
+		"/home/unittest/gowatch/index.tmpl":            true,
+		"/home/unittest/gowatch/go.mod":                true,
+		"/home/unittest/gowatch/go.sum":                false,
+		"/home/unittest/gowatch/schema.sql":            false,
+		"/home/unittest/gowatch/migrations/001.sql":    true,
+		"/home/unittest/gowatch/foo/migrations/01.sql": false,
+	} {
+		watch, err := w.isToWatchFile(file)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if watch != expected {
+			t.Errorf("%s: "+assertErrorMsg, file, expected, watch)
+		}
+	}
 }
 
 func TestDiscoverSubDirectories(t *testing.T) {
@@ -276,7 +690,7 @@ func TestDiscoverSubDirectories(t *testing.T) {
 	}
 	baseDir := fmt.Sprintf("%s/testdata/http-server", pwd)
 	expectedDirectories := []string{baseDir, fmt.Sprintf("%s/foo", baseDir)}
-	directories, err := discoverSubDirectories(baseDir)
+	directories, err := (&Watcher{dir: baseDir}).discoverSubDirectories(baseDir)
 	if err != nil {
 		t.Fatalf(unexpectedErrorMsg, err)
 	}
@@ -290,6 +704,123 @@ func TestDiscoverSubDirectories(t *testing.T) {
 	}
 }
 
+func TestDiscoverSubDirectoriesIgnore(t *testing.T) {
+	baseDir, err := createTmpDir("TestDiscoverSubDirectoriesIgnore")
+	if err != nil {
+		t.Fatalf(unexpectedErrorMsg, err)
+	}
+	defer os.RemoveAll(baseDir)
+	for _, dir := range []string{"vendor/foo", "internal/mocks", "internal/billing/mocks"} {
+		if err := os.MkdirAll(filepath.Join(baseDir, dir), 0755); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	w := Watcher{
+		dir:    baseDir,
+		ignore: []string{"vendor/**", "internal/**/mocks"},
+	}
+	directories, err := w.discoverSubDirectories(baseDir)
+	if err != nil {
+		t.Fatalf(unexpectedErrorMsg, err)
+	}
+	expectedDirectories := []string{baseDir, filepath.Join(baseDir, "internal"), filepath.Join(baseDir, "internal/billing")}
+	if len(directories) != len(expectedDirectories) {
+		t.Fatalf(assertErrorMsg, expectedDirectories, directories)
+	}
+	for i, dir := range directories {
+		if dir != expectedDirectories[i] {
+			t.Errorf(assertErrorMsg, expectedDirectories[i], dir)
+		}
+	}
+}
+
+func TestDiscoverSubDirectoriesGitignore(t *testing.T) {
+	baseDir, err := createTmpDir("TestDiscoverSubDirectoriesGitignore")
+	if err != nil {
+		t.Fatalf(unexpectedErrorMsg, err)
+	}
+	defer os.RemoveAll(baseDir)
+	for _, dir := range []string{"bin", "web/node_modules/foo", "web/src"} {
+		if err := os.MkdirAll(filepath.Join(baseDir, dir), 0755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	files := map[string]string{
+		".gitignore":     "/bin\n",
+		"web/.gitignore": "node_modules/\n",
+	}
+	for name, content := range files {
+		if err := ioutil.WriteFile(filepath.Join(baseDir, name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	w := Watcher{dir: baseDir, gitignore: &gitignore{}}
+	directories, err := w.discoverSubDirectories(baseDir)
+	if err != nil {
+		t.Fatalf(unexpectedErrorMsg, err)
+	}
+	expectedDirectories := []string{baseDir, filepath.Join(baseDir, "web"), filepath.Join(baseDir, "web/src")}
+	if len(directories) != len(expectedDirectories) {
+		t.Fatalf(assertErrorMsg, expectedDirectories, directories)
+	}
+	for i, dir := range directories {
+		if dir != expectedDirectories[i] {
+			t.Errorf(assertErrorMsg, expectedDirectories[i], dir)
+		}
+	}
+
+	ignore, err := w.isToIgnoreFile(filepath.Join(baseDir, "web/node_modules/foo/main.go"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !ignore {
+		t.Error("files of directories in .gitignore should be ignored")
+	}
+}
+
+func TestDiscoverSubDirectoriesExcludeDirs(t *testing.T) {
+	baseDir, err := createTmpDir("TestDiscoverSubDirectoriesExcludeDirs")
+	if err != nil {
+		t.Fatalf(unexpectedErrorMsg, err)
+	}
+	defer os.RemoveAll(baseDir)
+	for _, dir := range []string{".git/objects", ".idea", "vendor/foo", "web/node_modules/foo", "web/src"} {
+		if err := os.MkdirAll(filepath.Join(baseDir, dir), 0755); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	w := Watcher{dir: baseDir, excludeDirs: DefaultExcludeDirs}
+	directories, err := w.discoverSubDirectories(baseDir)
+	if err != nil {
+		t.Fatalf(unexpectedErrorMsg, err)
+	}
+	expectedDirectories := []string{baseDir, filepath.Join(baseDir, "web"), filepath.Join(baseDir, "web/src")}
+	if len(directories) != len(expectedDirectories) {
+		t.Fatalf(assertErrorMsg, expectedDirectories, directories)
+	}
+	for i, dir := range directories {
+		if dir != expectedDirectories[i] {
+			t.Errorf(assertErrorMsg, expectedDirectories[i], dir)
+		}
+	}
+}
+
+func TestWatchError(t *testing.T) {
+	err := watchError("/tmp/foo", syscall.ENOSPC)
+	if !errors.Is(err, ErrWatchLimit) {
+		t.Errorf(assertErrorMsg, ErrWatchLimit, err)
+	}
+	if !strings.Contains(err.Error(), "--poll") {
+		t.Errorf("error should suggest polling: %v", err)
+	}
+	if err := watchError("/tmp/foo", syscall.ENOENT); err != syscall.ENOENT {
+		t.Errorf(assertErrorMsg, syscall.ENOENT, err)
+	}
+}
+
 func TestGetCurrentFolderName(t *testing.T) {
 	dir := "/home/unittest/gowatch/testcase"
 	folderExpected := "testcase"
@@ -323,3 +854,20 @@ func TestContainsFalse(t *testing.T) {
 		t.Errorf("%s not exist in %v\n", value, list)
 	}
 }
+
+func TestRestartStartError(t *testing.T) {
+	p := newProcessTest()
+	target := &appTarget{app: appTestStartError{}, process: p, changes: map[string]bool{"main.go": true}}
+	w := Watcher{apps: []*appTarget{target}}
+	w.restartApp(target)
+	if err := w.restartFinished(<-w.restartDone); err != nil {
+		t.Fatalf(unexpectedErrorMsg, err)
+	}
+	defer target.stopRetry()
+	if target.process != nil {
+		t.Errorf("stopped process should not be kept: %v", target.process)
+	}
+	if target.retryTimer == nil {
+		t.Error("new build should be started again after the old process stopped")
+	}
+}